@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"hafiztri123/hv1-job-tracker/internal/config"
+	"os"
+)
+
+// runConfig implements the config subcommand, which prints the effective
+// configuration with secrets redacted, and returns the exit code. It takes
+// the same flags as the server.
+func runConfig(args []string) int {
+	cfg, err := config.Load(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	dump, err := cfg.Dump()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Print(dump)
+	return 0
+}
@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"hafiztri123/hv1-job-tracker/internal/auth"
 	"hafiztri123/hv1-job-tracker/internal/config"
 	"hafiztri123/hv1-job-tracker/internal/database"
 	"hafiztri123/hv1-job-tracker/internal/handler"
+	"hafiztri123/hv1-job-tracker/internal/health"
+	"hafiztri123/hv1-job-tracker/internal/logging"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
 	"hafiztri123/hv1-job-tracker/internal/router"
-	"hafiztri123/hv1-job-tracker/internal/utils"
+	"hafiztri123/hv1-job-tracker/internal/tracing"
 	"log/slog"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
@@ -23,19 +28,64 @@ func main() {
 		slog.Warn("godotenv failed to initialized. Using default value for env", "error", err)
 	}
 
-	isDev, err := strconv.ParseBool(utils.GetEnv("IS_DEV", "false"))
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		}
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		isDev = false
+		slog.Error("invalid config", "error", err)
+		os.Exit(2)
 	}
+
+	isDev := cfg.Server.Dev
 	slog.Info("dev mode", "mode", isDev)
 
 	startCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger := slog.New(tracing.NewLogHandler(logging.NewHandler(os.Stdout, cfg.Logging())))
 	slog.SetDefault(logger)
 
-	cfg := config.NewConfig()
+	shutdownTracing, err := tracing.Setup(startCtx, cfg.Features.TracesExporter)
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	keys, err := auth.LoadKeySet(cfg.Keys())
+	if err != nil {
+		slog.Error("failed to load jwt keys", "error", err)
+		os.Exit(1)
+	}
+
+	if !isDev && keys.UsesDefaultSecret() {
+		slog.Error("refusing to start in production with the default jwt secret, set JWT_SECRET or JWT_KEYS_DIR")
+		os.Exit(1)
+	}
+
+	if !isDev && cfg.TOTP.EncryptionKey == "" {
+		slog.Error("refusing to start in production without a key for two-factor secrets, set TOTP_ENCRYPTION_KEY")
+		os.Exit(1)
+	}
+
+	if !isDev && cfg.Mail.SMTPHost == "" {
+		slog.Error("refusing to start in production without an SMTP server, set SMTP_HOST")
+		os.Exit(1)
+	}
+
+	auth.SetKeySet(keys)
+	slog.Info("jwt signing key loaded", "kid", keys.ActiveKeyID())
+
+	slog.Info("auth cookie mode", "enabled", cfg.Auth.CookieMode)
 
 	db, err := database.NewDatabase(cfg, startCtx)
 
@@ -46,15 +96,48 @@ func main() {
 
 	defer db.Close()
 
-	repos := config.NewRepositories(db.Pool)
-	services := config.NewService(repos)
+	if cfg.DB.AutoMigrate {
+		if err := autoMigrate(startCtx, db); err != nil {
+			slog.Error("failed to migrate database", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	appMetrics := newMetrics(cfg.Metrics(), db)
+
+	repos := newRepositories(cfg, db)
+	services, err := config.NewService(cfg, repos, appMetrics)
+	if err != nil {
+		slog.Error("failed to set up services", "error", err)
+		os.Exit(1)
+	}
+
+	services.Health, err = newHealthChecker(cfg, db)
+	if err != nil {
+		slog.Error("invalid readiness config", "error", err)
+		os.Exit(1)
+	}
+
 	handler := handler.NewHandler(services)
-	app := router.NewRouter(handler, config.NewRouterConfig(isDev), isDev)
+	app := router.NewRouter(handler, cfg)
+
+	purgeCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	go services.AccountService.RunPurger(purgeCtx, time.Hour)
+	go services.Idempotency.RunPurger(purgeCtx, time.Hour)
+
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	defer stopMetrics()
+	go func() {
+		if err := appMetrics.Listen(metricsCtx); err != nil {
+			slog.Error("failed to serve metrics", "error", err)
+		}
+	}()
 
-	appPort := utils.GetEnv("APP_PORT", "3000")
+	appPort := cfg.Server.Port
 
 	go func() {
-		if err := app.Listen(fmt.Sprintf(":%s", appPort)); err != nil {
+		if err := app.Listen(fmt.Sprintf(":%d", appPort)); err != nil {
 			slog.Error("failed to start server", "error", err)
 			os.Exit(1)
 		}
@@ -69,8 +152,67 @@ func main() {
 
 	slog.Info("Starting graceful shutdown...")
 
+	// Fail readiness first and give load balancers time to notice before
+	// the listener goes away.
+	services.Health.Shutdown()
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
+	stopPurger()
+	stopMetrics()
+
 	if err := app.ShutdownWithTimeout(30 * time.Second); err != nil {
 		slog.Error("failed to gracefully shutdown", "error", err)
 		os.Exit(1)
 	}
+
+	// Flush the spans of the last requests.
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+}
+
+func newRepositories(cfg *config.Config, db *database.Database) *config.Repositories {
+	if db.SQLite != nil {
+		slog.Info("using sqlite storage", "path", cfg.DB.SQLitePath)
+		return config.NewSQLiteRepositories(db.SQLite, cfg.DB.Timeouts())
+	}
+
+	return config.NewRepositories(db.Pool, cfg.DB.Timeouts())
+}
+
+func newMetrics(cfg metrics.Config, db *database.Database) *metrics.Metrics {
+	m := metrics.New(cfg)
+
+	if db.SQLite != nil {
+		m.RegisterSQLDB(db.SQLite)
+	} else {
+		m.RegisterPgxPool(db.Pool)
+	}
+
+	return m
+}
+
+func newHealthChecker(cfg *config.Config, db *database.Database) (*health.Checker, error) {
+	migrator, err := db.Migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []health.Check{
+		{Name: "database", Run: db.Ping},
+		{Name: "migrations", Run: migrator.CheckCurrent},
+	}
+
+	if cfg.Features.RedisURL != "" {
+		redis, err := health.Redis(cfg.Features.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+
+		checks = append(checks, redis)
+	}
+
+	return health.NewChecker(checks...), nil
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"hafiztri123/hv1-job-tracker/internal/config"
+	"hafiztri123/hv1-job-tracker/internal/database"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+const migrateUsage = `usage: server migrate [flags] <command>
+
+flags are the server's, e.g. -config or -db.host, and go before the command.
+
+commands:
+  up           apply all pending migrations
+  down [n]     roll back the last n migrations (default 1)
+  status       show the current version and pending migrations
+  force <v>    set the version without running anything and clear the dirty flag`
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(args []string) int {
+	cfg, args, err := config.LoadArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 0
+	}
+	if err != nil {
+		slog.Error("invalid config", "error", err)
+		return 2
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	db, err := database.NewDatabase(cfg, ctx)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		slog.Error("failed to load migrations", "error", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("migrate up failed", "error", err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, "down expects a positive number of steps")
+				return 2
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			slog.Error("migrate down failed", "error", err)
+			return 1
+		}
+		fmt.Printf("rolled back %d migration(s)\n", reverted)
+
+	case "status":
+		version, dirty, statuses, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("migrate status failed", "error", err)
+			return 1
+		}
+
+		fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("  %06d_%s  %s\n", status.Version, status.Name, state)
+		}
+
+	case "force":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "force expects a version")
+			return 2
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < database.NilVersion {
+			fmt.Fprintln(os.Stderr, "force expects a version, or -1 for an empty database")
+			return 2
+		}
+
+		if err := migrator.Force(ctx, version); err != nil {
+			slog.Error("migrate force failed", "error", err)
+			return 1
+		}
+		fmt.Printf("forced version %d\n", version)
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// autoMigrate applies pending migrations on start. On Postgres the advisory
+// lock inside Migrator makes concurrent replicas wait for whichever got there
+// first.
+func autoMigrate(ctx context.Context, db *database.Database) error {
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("database migrated", "applied", applied)
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package account
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Export loads everything stored about the user. Loading happens before the
+// response starts, so a failure still turns into an error response; only
+// writing the archive is streamed.
+func (s *AccountService) Export(ctx context.Context, userId string) (*Export, error) {
+	profile, err := s.users.GetProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	applications, err := s.applications.ExportApplications(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.users.GetSessions(ctx, userId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.users.GetIdentities(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokens.GetTokens(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{
+		files: []exportFile{
+			{"profile.json", profile},
+			{"applications.json", applications},
+			{"sessions.json", sessions},
+			{"identities.json", identities},
+			{"access_tokens.json", tokens},
+		},
+		exportedAt: time.Now(),
+	}, nil
+}
+
+// WriteZip writes the export as a ZIP archive, one JSON document per kind of
+// data. Secrets such as password and token hashes are left out.
+func (e *Export) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	for _, file := range e.files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: e.exportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func (s *AccountService) Delete(ctx context.Context, userId string, req *user.DeleteAccountDto) (*DeletionResponse, error) {
+	deletedAt, err := s.users.DeleteAccount(ctx, userId, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeletionResponse{
+		DeletedAt:     deletedAt,
+		PurgeAfter:    deletedAt.Add(s.gracePeriod),
+		GracePeriodMs: s.gracePeriod.Milliseconds(),
+	}, nil
+}
+
+// RunPurger hard-deletes accounts whose grace period has ended, once per
+// interval, until ctx is cancelled.
+func (s *AccountService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.users.PurgeDeletedAccounts(ctx, s.gracePeriod)
+		if err != nil {
+			slog.Error("failed to purge deleted accounts", "error", err)
+		} else if purged > 0 {
+			slog.Info("purged deleted accounts", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
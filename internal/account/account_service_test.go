@@ -0,0 +1,164 @@
+package account_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"hafiztri123/hv1-job-tracker/internal/account"
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/database/dbtest"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"hafiztri123/hv1-job-tracker/internal/token"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportAndPurge(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.SQLite(t)
+
+	users := user.NewUserService(user.NewSQLiteUserRepository(db, storage.DefaultTimeouts), mail.LogMailer{}, nil, nil, user.TwoFactorConfig{}, "", time.Hour)
+	applicationService := applications.NewApplicationService(applications.NewSQLiteApplicationRepository(db, storage.DefaultTimeouts))
+	tokens := token.NewTokenService(token.NewSQLiteTokenRepository(db, storage.DefaultTimeouts))
+	service := account.NewAccountService(users, applicationService, tokens, time.Hour)
+
+	identity := &user.ExternalIdentity{Provider: "github", Subject: "42", Email: "export@example.com", EmailVerified: true, FirstName: "Ann"}
+	if _, err := users.LoginWithIdentity(ctx, identity, user.ClientInfo{UserAgent: "test"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created, err := users.Repo.FindUserByEmail(ctx, identity.Email)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	userId := created.ID.String()
+
+	if err := applicationService.CreateApplication(ctx, &applications.CreateApplicationDto{CompanyName: "Acme", PositionTitle: "Engineer"}, userId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tokens.CreateToken(ctx, userId, &token.CreateTokenDto{Name: "cli", Scopes: []string{"read:applications"}, ExpiresInDays: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	export, err := service.Export(ctx, userId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteZip(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := make(map[string][]any)
+	for _, file := range archive.File {
+		entries[file.Name] = readEntry(t, file)
+	}
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	want := []string{"access_tokens.json", "applications.json", "identities.json", "profile.json", "sessions.json"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected entries %v, got %v", want, names)
+	}
+
+	for _, name := range []string{"access_tokens.json", "applications.json", "identities.json", "sessions.json"} {
+		if len(entries[name]) != 1 {
+			t.Errorf("expected one record in %s, got %d", name, len(entries[name]))
+		}
+	}
+
+	for _, file := range archive.File {
+		raw := readRaw(t, file)
+		if strings.Contains(raw, "Hash") || strings.Contains(raw, "hash") {
+			t.Errorf("expected %s to leave out hashes, got %s", file.Name, raw)
+		}
+	}
+
+	if _, err := service.Delete(ctx, userId, &user.DeleteAccountDto{ConfirmEmail: identity.Email}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tables := []string{"applications", "personal_access_tokens", "user_sessions", "user_identities"}
+	for _, table := range tables {
+		if n := countRows(t, db, table, userId); n != 1 {
+			t.Fatalf("expected the deleted account to keep its %s until the purge, got %d", table, n)
+		}
+	}
+
+	// Timestamps are in milliseconds, so a cutoff of now can equal deleted_at.
+	purged, err := users.PurgeDeletedAccounts(ctx, -time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged account, got %d", purged)
+	}
+
+	for _, table := range tables {
+		if n := countRows(t, db, table, userId); n != 0 {
+			t.Errorf("expected no %s left after the purge, got %d", table, n)
+		}
+	}
+}
+
+func readRaw(t *testing.T, file *zip.File) string {
+	t.Helper()
+
+	r, err := file.Open()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return string(raw)
+}
+
+// readEntry decodes an entry into a list of records; a single object counts
+// as one record.
+func readEntry(t *testing.T, file *zip.File) []any {
+	t.Helper()
+
+	var data any
+	if err := json.Unmarshal([]byte(readRaw(t, file)), &data); err != nil {
+		t.Fatalf("%s is not JSON: %v", file.Name, err)
+	}
+
+	if list, ok := data.([]any); ok {
+		return list
+	}
+
+	return []any{data}
+}
+
+func countRows(t *testing.T, db *sql.DB, table, userId string) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(`select count(*) from `+table+` where user_id = $1`, userId).Scan(&n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return n
+}
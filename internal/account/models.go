@@ -0,0 +1,40 @@
+package account
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/token"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"time"
+)
+
+type AccountService struct {
+	users        *user.UserService
+	applications *applications.ApplicationService
+	tokens       *token.TokenService
+	gracePeriod  time.Duration
+}
+
+func NewAccountService(users *user.UserService, applications *applications.ApplicationService, tokens *token.TokenService, gracePeriod time.Duration) *AccountService {
+	return &AccountService{
+		users:        users,
+		applications: applications,
+		tokens:       tokens,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+type Export struct {
+	files      []exportFile
+	exportedAt time.Time
+}
+
+type exportFile struct {
+	name string
+	data any
+}
+
+type DeletionResponse struct {
+	DeletedAt     time.Time `json:"deletedAt"`
+	PurgeAfter    time.Time `json:"purgeAfter"`
+	GracePeriodMs int64     `json:"gracePeriodMs"`
+}
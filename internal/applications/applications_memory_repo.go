@@ -0,0 +1,327 @@
+package applications
+
+import (
+	"context"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryApplicationRepository is an in-memory Repository for tests. It
+// mirrors the Postgres behaviour, including the errors it returns.
+type MemoryApplicationRepository struct {
+	mu           sync.RWMutex
+	applications map[uuid.UUID]*Application
+}
+
+func NewMemoryApplicationRepository() *MemoryApplicationRepository {
+	return &MemoryApplicationRepository{
+		applications: make(map[uuid.UUID]*Application),
+	}
+}
+
+func (r *MemoryApplicationRepository) InsertApplication(_ context.Context, req *CreateApplicationDto, userId string) error {
+	owner, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	app := &Application{
+		Id:            uuid.New(),
+		UserId:        owner,
+		CompanyName:   req.CompanyName,
+		PositionTitle: req.PositionTitle,
+		JobUrl:        clonePtr(req.JobUrl),
+		SalaryRange:   clonePtr(req.SalaryRange),
+		Location:      clonePtr(req.Location),
+		Status:        clonePtr(req.Status),
+		Notes:         clonePtr(req.Notes),
+		AppliedDate:   clonePtr(req.AppliedDate),
+		CreatedAt:     time.Now(),
+		Version:       1,
+	}
+
+	r.applications[app.Id] = app
+
+	return nil
+}
+
+func (r *MemoryApplicationRepository) FindApplicationsById(_ context.Context, userId string, queryParams ApplicationQueryParams) ([]Application, error) {
+	return r.find(userId, func(app *Application) bool {
+		if app.DeletedAt != nil {
+			return false
+		}
+
+		return queryParams.Status == nil || (app.Status != nil && *app.Status == *queryParams.Status)
+	}), nil
+}
+
+func (r *MemoryApplicationRepository) FindApplicationById(_ context.Context, userId, applicationId string) (*Application, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	app, ok := r.active(userId, applicationId)
+	if !ok {
+		return nil, appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	c := cloneApplication(app)
+	return &c, nil
+}
+
+func (r *MemoryApplicationRepository) FindAllApplicationsByUserId(_ context.Context, userId string) ([]Application, error) {
+	return r.find(userId, func(*Application) bool { return true }), nil
+}
+
+func (r *MemoryApplicationRepository) DeleteApplications(_ context.Context, userId, applicationId string, version *int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	app, ok := r.active(userId, applicationId)
+	if !ok {
+		return appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	if version != nil && *version != app.Version {
+		return errVersionMismatch()
+	}
+
+	now := time.Now()
+	app.DeletedAt = &now
+	app.Version++
+
+	return nil
+}
+
+func (r *MemoryApplicationRepository) UpdateApplications(_ context.Context, userId, applicationId string, version *int64, patch *ApplicationPatch) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	app, ok := r.active(userId, applicationId)
+	if !ok {
+		return 0, appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	if version != nil && *version != app.Version {
+		return 0, errVersionMismatch()
+	}
+
+	body := &patch.Set
+
+	if body.CompanyName != nil {
+		app.CompanyName = *body.CompanyName
+	}
+	if body.PositionTitle != nil {
+		app.PositionTitle = *body.PositionTitle
+	}
+	if body.JobUrl != nil {
+		app.JobUrl = clonePtr(body.JobUrl)
+	}
+	if body.SalaryRange != nil {
+		app.SalaryRange = clonePtr(body.SalaryRange)
+	}
+	if body.Location != nil {
+		app.Location = clonePtr(body.Location)
+	}
+	if body.Status != nil {
+		app.Status = clonePtr(body.Status)
+	}
+	if body.Notes != nil {
+		app.Notes = clonePtr(body.Notes)
+	}
+	if body.AppliedDate != nil {
+		app.AppliedDate = clonePtr(body.AppliedDate)
+	}
+
+	for _, field := range patch.Clear {
+		switch field {
+		case "jobUrl":
+			app.JobUrl = nil
+		case "salaryRange":
+			app.SalaryRange = nil
+		case "location":
+			app.Location = nil
+		case "notes":
+			app.Notes = nil
+		case "appliedDate":
+			app.AppliedDate = nil
+		}
+	}
+
+	now := time.Now()
+	app.UpdatedAt = &now
+	app.Version++
+
+	return app.Version, nil
+}
+
+func (r *MemoryApplicationRepository) BatchDeleteApplications(_ context.Context, userId string, applicationIds []string, versions map[string]int64) error {
+	if len(applicationIds) == 0 {
+		return appError.NewBadRequestError("No application IDs provided")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersions(userId, applicationIds, versions); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	affected := 0
+
+	for _, id := range applicationIds {
+		if app, ok := r.active(userId, id); ok {
+			app.DeletedAt = &now
+			app.Version++
+			affected++
+		}
+	}
+
+	if affected == 0 {
+		return appError.NewNotFoundErr("No applications found to delete").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
+
+func (r *MemoryApplicationRepository) BatchUpdateStatusApplications(_ context.Context, userId string, applicationIds []string, status string, versions map[string]int64) error {
+	if len(applicationIds) == 0 {
+		return appError.NewBadRequestError("No application IDs provided")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersions(userId, applicationIds, versions); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	affected := 0
+
+	for _, id := range applicationIds {
+		if app, ok := r.active(userId, id); ok {
+			app.Status = &status
+			app.UpdatedAt = &now
+			app.Version++
+			affected++
+		}
+	}
+
+	if affected == 0 {
+		return appError.NewNotFoundErr("No applications found to update").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
+
+func (r *MemoryApplicationRepository) CountApplicationsByStatus(_ context.Context) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int64)
+
+	for _, app := range r.applications {
+		if app.DeletedAt != nil {
+			continue
+		}
+
+		status := ""
+		if app.Status != nil {
+			status = *app.Status
+		}
+
+		counts[status]++
+	}
+
+	return counts, nil
+}
+
+// active returns the user's application if it exists and isn't deleted. The
+// caller must hold the lock.
+func (r *MemoryApplicationRepository) active(userId, applicationId string) (*Application, bool) {
+	id, err := uuid.Parse(applicationId)
+	if err != nil {
+		return nil, false
+	}
+
+	app, ok := r.applications[id]
+	if !ok || app.UserId.String() != userId || app.DeletedAt != nil {
+		return nil, false
+	}
+
+	return app, true
+}
+
+// checkVersions fails when one of the applications has another version than
+// the client expects, or is gone. The caller must hold the lock.
+func (r *MemoryApplicationRepository) checkVersions(userId string, applicationIds []string, versions map[string]int64) error {
+	for _, id := range applicationIds {
+		version, ok := versions[id]
+		if !ok {
+			continue
+		}
+
+		app, ok := r.active(userId, id)
+		if !ok {
+			return appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+		}
+
+		if app.Version != version {
+			return errVersionMismatch()
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryApplicationRepository) find(userId string, keep func(*Application) bool) []Application {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	applications := []Application{}
+
+	for _, app := range r.applications {
+		if app.UserId.String() == userId && keep(app) {
+			applications = append(applications, cloneApplication(app))
+		}
+	}
+
+	sort.Slice(applications, func(i, j int) bool {
+		return applications[i].CreatedAt.Before(applications[j].CreatedAt)
+	})
+
+	return applications
+}
+
+// cloneApplication copies the pointer fields too, so callers can't change
+// the stored application behind the lock's back.
+func cloneApplication(app *Application) Application {
+	c := *app
+	c.JobUrl = clonePtr(app.JobUrl)
+	c.SalaryRange = clonePtr(app.SalaryRange)
+	c.Location = clonePtr(app.Location)
+	c.Status = clonePtr(app.Status)
+	c.Notes = clonePtr(app.Notes)
+	c.AppliedDate = clonePtr(app.AppliedDate)
+	c.UpdatedAt = clonePtr(app.UpdatedAt)
+	c.DeletedAt = clonePtr(app.DeletedAt)
+
+	return c
+}
+
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	return &c
+}
@@ -2,12 +2,14 @@ package applications
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	appError "hafiztri123/hv1-job-tracker/internal/error"
-	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
-func (r *ApplicationRepository) InsertApplication(req *CreateApplicationDto, userId string) error {
+func (r *ApplicationRepository) InsertApplication(ctx context.Context, req *CreateApplicationDto, userId string) error {
 	createQuery := `
 		insert into applications (
 			user_id,
@@ -22,7 +24,7 @@ func (r *ApplicationRepository) InsertApplication(req *CreateApplicationDto, use
 		) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := r.timeouts.ForWrite(ctx)
 	defer cancel()
 
 	_, err := r.db.Exec(ctx, createQuery, userId, req.CompanyName, req.PositionTitle, req.JobUrl, req.SalaryRange, req.Location, req.Status, req.Notes, req.AppliedDate)
@@ -35,7 +37,7 @@ func (r *ApplicationRepository) InsertApplication(req *CreateApplicationDto, use
 
 }
 
-func (r *ApplicationRepository) FindApplicationsById(userId string, queryParams ApplicationQueryParams) ([]Application, error) {
+func (r *ApplicationRepository) FindApplicationsById(ctx context.Context, userId string, queryParams ApplicationQueryParams) ([]Application, error) {
 	fetchQuery := `
 		select 
 		id, 
@@ -50,7 +52,8 @@ func (r *ApplicationRepository) FindApplicationsById(userId string, queryParams
 		applied_date, 
 		created_at, 
 		updated_at, 
-		deleted_at
+		deleted_at,
+		version
 		from applications where user_id = $1 and deleted_at is null
 	`
 
@@ -63,7 +66,7 @@ func (r *ApplicationRepository) FindApplicationsById(userId string, queryParams
 		args = append(args, *queryParams.Status)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	ctx, cancel := r.timeouts.ForRead(ctx)
 	defer cancel()
 
 	rows, err := r.db.Query(ctx, fetchQuery, args...)
@@ -91,6 +94,7 @@ func (r *ApplicationRepository) FindApplicationsById(userId string, queryParams
 			&app.CreatedAt,
 			&app.UpdatedAt,
 			&app.DeletedAt,
+			&app.Version,
 		)
 
 		if err != nil {
@@ -107,37 +111,95 @@ func (r *ApplicationRepository) FindApplicationsById(userId string, queryParams
 	return applications, err
 }
 
-func (r *ApplicationRepository) DeleteApplications(userId, applicationId string) error {
+func (r *ApplicationRepository) FindApplicationById(ctx context.Context, userId, applicationId string) (*Application, error) {
+	fetchQuery := `
+		select 
+		id, 
+		user_id, 
+		company_name, 
+		position_title, 
+		job_url, 
+		salary_range, 
+		location, 
+		status, 
+		notes, 
+		applied_date, 
+		created_at, 
+		updated_at, 
+		deleted_at,
+		version
+		from applications where id = $1 and user_id = $2 and deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	app := new(Application)
+
+	err := r.db.QueryRow(ctx, fetchQuery, applicationId, userId).Scan(
+		&app.Id,
+		&app.UserId,
+		&app.CompanyName,
+		&app.PositionTitle,
+		&app.JobUrl,
+		&app.SalaryRange,
+		&app.Location,
+		&app.Status,
+		&app.Notes,
+		&app.AppliedDate,
+		&app.CreatedAt,
+		&app.UpdatedAt,
+		&app.DeletedAt,
+		&app.Version,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+		}
+
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return app, nil
+}
+
+func (r *ApplicationRepository) DeleteApplications(ctx context.Context, userId, applicationId string, version *int64) error {
 	updateQuery := `
 		update applications
-		set deleted_at = now()
-		where id = $1 and user_id = $2 and deleted_at is null;
+		set deleted_at = now(), version = version + 1
+		where id = $1 and user_id = $2 and deleted_at is null
 	`
+	args := []any{applicationId, userId}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	if version != nil {
+		updateQuery += " and version = $3"
+		args = append(args, *version)
+	}
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
 	defer cancel()
 
-	result, err := r.db.Exec(ctx, updateQuery, applicationId, userId)
+	result, err := r.db.Exec(ctx, updateQuery, args...)
 	if err != nil {
-		return appError.NewInternalServerError(err.Error())
+		return appError.WrapInternalServerError(err)
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return appError.NewNotFoundErr("Application not found")
+		return r.notUpdated(ctx, r.db, userId, applicationId, version)
 	}
 
 	return nil
 }
 
-func (r *ApplicationRepository) UpdateApplications(userId, applicationId string, body *UpdateApplicationDto) error {
+func (r *ApplicationRepository) UpdateApplications(ctx context.Context, userId, applicationId string, version *int64, patch *ApplicationPatch) (int64, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
 	defer cancel()
 
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return appError.NewInternalServerError(err.Error())
+		return 0, appError.WrapInternalServerError(err)
 	}
 	defer func() {
 		err = tx.Rollback(ctx)
@@ -146,9 +208,10 @@ func (r *ApplicationRepository) UpdateApplications(userId, applicationId string,
 		}
 	}()
 
-	query := "update applications set updated_at = now()"
+	query := "update applications set updated_at = now(), version = version + 1"
 	args := []any{}
 	paramCount := 0
+	body := &patch.Set
 
 	if body.CompanyName != nil {
 		paramCount++
@@ -198,74 +261,285 @@ func (r *ApplicationRepository) UpdateApplications(userId, applicationId string,
 		args = append(args, *body.AppliedDate)
 	}
 
+	for _, field := range patch.Clear {
+		if column, ok := nullableColumns[field]; ok {
+			query += fmt.Sprintf(" , %s = null", column)
+		}
+	}
+
 	query += fmt.Sprintf(" where id = $%d and user_id = $%d and deleted_at is null", paramCount+1, paramCount+2)
 	args = append(args, applicationId, userId)
 
-	result, err := tx.Exec(ctx, query, args...)
-	if err != nil {
-		return appError.NewInternalServerError(err.Error())
+	if version != nil {
+		query += fmt.Sprintf(" and version = $%d", paramCount+3)
+		args = append(args, *version)
 	}
 
-	if result.RowsAffected() == 0 {
-		return appError.NewNotFoundErr("Application not found")
+	query += " returning version"
+
+	var updated int64
+	if err := tx.QueryRow(ctx, query, args...).Scan(&updated); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, r.notUpdated(ctx, tx, userId, applicationId, version)
+		}
+
+		return 0, appError.WrapInternalServerError(err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return appError.NewInternalServerError(err.Error())
+		return 0, appError.WrapInternalServerError(err)
 	}
 
-	return nil
+	return updated, nil
 
 }
 
-func (r *ApplicationRepository) BatchDeleteApplications(userId string, applicationIds []string) error {
+func (r *ApplicationRepository) BatchDeleteApplications(ctx context.Context, userId string, applicationIds []string, versions map[string]int64) error {
 	if len(applicationIds) == 0 {
 		return appError.NewBadRequestError("No application IDs provided")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
 	defer cancel()
 
 	query := `
 		update applications
-		set deleted_at = now()
+		set deleted_at = now(), version = version + 1
 		where id = ANY($1) and user_id = $2 and deleted_at is null
 	`
 
-	result, err := r.db.Exec(ctx, query, applicationIds, userId)
-	if err != nil {
-		return appError.NewInternalServerError(err.Error())
-	}
-
-	if result.RowsAffected() == 0 {
-		return appError.NewNotFoundErr("No applications found to delete")
-	}
-
-	return nil
+	return r.batch(ctx, userId, applicationIds, versions, "No applications found to delete", query, applicationIds, userId)
 }
 
-func (r *ApplicationRepository) BatchUpdateStatusApplications(userId string, applicationIds []string, status string) error {
+func (r *ApplicationRepository) BatchUpdateStatusApplications(ctx context.Context, userId string, applicationIds []string, status string, versions map[string]int64) error {
 	if len(applicationIds) == 0 {
 		return appError.NewBadRequestError("No application IDs provided")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
 	defer cancel()
 
 	query := `
 		update applications
-		set status = $1, updated_at = now()
+		set status = $1, updated_at = now(), version = version + 1
 		where id = ANY($2) and user_id = $3 and deleted_at is null
 	`
 
-	result, err := r.db.Exec(ctx, query, status, applicationIds, userId)
+	return r.batch(ctx, userId, applicationIds, versions, "No applications found to update", query, status, applicationIds, userId)
+}
+
+// batch runs a batch update in a transaction with the version check, so
+// none of the applications can change in between.
+func (r *ApplicationRepository) batch(ctx context.Context, userId string, applicationIds []string, versions map[string]int64, notFound, query string, args ...any) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return appError.NewInternalServerError(err.Error())
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	if err := checkVersions(ctx, tx, userId, applicationIds, versions); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return appError.NewNotFoundErr("No applications found to update")
+		return appError.NewNotFoundErr(notFound).WithCode(appError.CodeApplicationNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return appError.WrapInternalServerError(err)
 	}
 
 	return nil
 }
+
+// checkVersions locks the applications that have an expected version and
+// compares them. One that is missing, deleted or someone else's is not found.
+func checkVersions(ctx context.Context, tx pgx.Tx, userId string, applicationIds []string, versions map[string]int64) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	lockQuery := `
+		select id::text, version
+		from applications
+		where id = ANY($1) and user_id = $2 and deleted_at is null
+		for update
+	`
+
+	rows, err := tx.Query(ctx, lockQuery, applicationIds, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer rows.Close()
+
+	seen := 0
+	for rows.Next() {
+		var id string
+		var version int64
+
+		if err := rows.Scan(&id, &version); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		expected, ok := versions[id]
+		if !ok {
+			continue
+		}
+
+		if expected != version {
+			return errVersionMismatch()
+		}
+		seen++
+	}
+
+	if err := rows.Err(); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if seen < len(versions) {
+		return appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
+
+// notUpdated tells why a conditional update of an application changed
+// nothing: it is gone, or it is there with another version.
+func (r *ApplicationRepository) notUpdated(ctx context.Context, db pgQuerier, userId, applicationId string, version *int64) error {
+	notFound := appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	if version == nil {
+		return notFound
+	}
+
+	existsQuery := `select exists (select 1 from applications where id = $1 and user_id = $2 and deleted_at is null)`
+
+	var exists bool
+	if err := db.QueryRow(ctx, existsQuery, applicationId, userId).Scan(&exists); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if !exists {
+		return notFound
+	}
+
+	return errVersionMismatch()
+}
+
+type pgQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// FindAllApplicationsByUserId returns every application of the user,
+// including soft-deleted ones. It backs the account data export.
+func (r *ApplicationRepository) FindAllApplicationsByUserId(ctx context.Context, userId string) ([]Application, error) {
+	fetchQuery := `
+		select 
+		id, 
+		user_id, 
+		company_name, 
+		position_title, 
+		job_url, 
+		salary_range, 
+		location, 
+		status, 
+		notes, 
+		applied_date, 
+		created_at, 
+		updated_at, 
+		deleted_at,
+		version
+		from applications where user_id = $1
+		order by created_at
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := []Application{}
+
+	for rows.Next() {
+		app := new(Application)
+
+		err := rows.Scan(
+			&app.Id,
+			&app.UserId,
+			&app.CompanyName,
+			&app.PositionTitle,
+			&app.JobUrl,
+			&app.SalaryRange,
+			&app.Location,
+			&app.Status,
+			&app.Notes,
+			&app.AppliedDate,
+			&app.CreatedAt,
+			&app.UpdatedAt,
+			&app.DeletedAt,
+			&app.Version,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		applications = append(applications, *app)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func (r *ApplicationRepository) CountApplicationsByStatus(ctx context.Context) (map[string]int64, error) {
+	countQuery := `
+		select coalesce(status, ''), count(*)
+		from applications
+		where deleted_at is null
+		group by 1
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, countQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+
+	for rows.Next() {
+		var status string
+		var count int64
+
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -1,21 +1,29 @@
 package applications
 
-func (s *ApplicationService) CreateApplication(req *CreateApplicationDto, userId string) error {
+import (
+	"context"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+func (s *ApplicationService) CreateApplication(ctx context.Context, req *CreateApplicationDto, userId string) error {
 
 	if req.Status == nil {
 		req.Status = new(string)
 		*req.Status = "Wishlist"
 	}
 
-	if err := s.repo.InsertApplication(req, userId); err != nil {
+	if err := s.repo.InsertApplication(ctx, req, userId); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (s *ApplicationService) GetApplications(userId string, queryParams ApplicationQueryParams) ([]Application, error) {
-	applications, err := s.repo.FindApplicationsById(userId, queryParams)
+func (s *ApplicationService) GetApplications(ctx context.Context, userId string, queryParams ApplicationQueryParams) ([]Application, error) {
+	applications, err := s.repo.FindApplicationsById(ctx, userId, queryParams)
 
 	if err != nil {
 		return nil, err
@@ -24,10 +32,30 @@ func (s *ApplicationService) GetApplications(userId string, queryParams Applicat
 	return applications, nil
 }
 
-func (s *ApplicationService) DeleteApplications(userId, applicationId string) error {
-	err := s.repo.DeleteApplications(userId, applicationId)
+// checkApplicationId fails with 404 for an id that isn't a UUID, which
+// can't name an application, rather than with a database error.
+func checkApplicationId(applicationId string) error {
+	if _, err := uuid.Parse(applicationId); err != nil {
+		return appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
+
+func (s *ApplicationService) GetApplication(ctx context.Context, userId, applicationId string) (*Application, error) {
+	if err := checkApplicationId(applicationId); err != nil {
+		return nil, err
+	}
 
-	return err
+	return s.repo.FindApplicationById(ctx, userId, applicationId)
+}
+
+func (s *ApplicationService) DeleteApplications(ctx context.Context, userId, applicationId string, version *int64) error {
+	if err := checkApplicationId(applicationId); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteApplications(ctx, userId, applicationId, version)
 }
 
 func (s *ApplicationService) GetApplicationOptions(queryParams ApplicationOptionQueryParams) ApplicationOptions {
@@ -36,22 +64,103 @@ func (s *ApplicationService) GetApplicationOptions(queryParams ApplicationOption
 	}
 
 	if queryParams.StatusOption {
-		options.StatusOption = []string{"Wishlist", "Applied", "Interviewing", "Offer", "Rejected"}
+		options.StatusOption = slices.Clone(Statuses)
 	}
 
 	return options
 }
 
-func (s *ApplicationService) UpdateApplication(body UpdateApplicationDto, userId, applicationId string) error {
+// UpdateApplication returns the new version of the application.
+func (s *ApplicationService) UpdateApplication(ctx context.Context, body UpdateApplicationDto, userId, applicationId string, version *int64) (int64, error) {
+	return s.PatchApplication(ctx, &ApplicationPatch{Set: body}, userId, applicationId, version)
+}
+
+// PatchApplication returns the new version of the application.
+func (s *ApplicationService) PatchApplication(ctx context.Context, patch *ApplicationPatch, userId, applicationId string, version *int64) (int64, error) {
+	if err := checkApplicationId(applicationId); err != nil {
+		return 0, err
+	}
+
+	return s.repo.UpdateApplications(ctx, userId, applicationId, version, patch)
+}
+
+func (s *ApplicationService) BatchDeleteApplications(ctx context.Context, userId string, req *BatchDeleteDto) error {
+	ids, versions, err := normalizeBatch(req.ApplicationIds, req.Versions)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.BatchDeleteApplications(ctx, userId, ids, versions)
+}
+
+func (s *ApplicationService) BatchUpdateStatusApplications(ctx context.Context, userId string, req *BatchUpdateStatusDto) error {
+	ids, versions, err := normalizeBatch(req.ApplicationIds, req.Versions)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.BatchUpdateStatusApplications(ctx, userId, ids, req.Status, versions)
+}
+
+// normalizeBatch spells every id of a batch the way the repositories store
+// them, so that versions keyed by an uppercase or braced id still match. A
+// version for an application outside the batch is a client mistake.
+func normalizeBatch(applicationIds []string, versions map[string]int64) ([]string, map[string]int64, error) {
+	ids := make([]string, len(applicationIds))
+	inBatch := make(map[string]bool, len(applicationIds))
+
+	for i, id := range applicationIds {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, nil, appError.NewBadRequestError("Application ids must be UUIDs")
+		}
+
+		ids[i] = parsed.String()
+		inBatch[ids[i]] = true
+	}
+
+	if versions == nil {
+		return ids, nil, nil
+	}
 
-	return s.repo.UpdateApplications(userId, applicationId, &body)
+	normalized := make(map[string]int64, len(versions))
+	for id, version := range versions {
+		parsed, err := uuid.Parse(id)
+		if err != nil || !inBatch[parsed.String()] {
+			return nil, nil, appError.NewBadRequestError("Versions can only be given for applications in applicationIds")
+		}
 
+		normalized[parsed.String()] = version
+	}
+
+	return ids, normalized, nil
 }
 
-func (s *ApplicationService) BatchDeleteApplications(userId string, req *BatchDeleteDto) error {
-	return s.repo.BatchDeleteApplications(userId, req.ApplicationIds)
+func (s *ApplicationService) ExportApplications(ctx context.Context, userId string) ([]Application, error) {
+	return s.repo.FindAllApplicationsByUserId(ctx, userId)
 }
 
-func (s *ApplicationService) BatchUpdateStatusApplications(userId string, req *BatchUpdateStatusDto) error {
-	return s.repo.BatchUpdateStatusApplications(userId, req.ApplicationIds, req.Status)
+// CountApplicationsByStatus counts the applications of every user per
+// status. Status is free text, so anything but the suggested Statuses is
+// counted as StatusOther, which keeps the set of keys bounded.
+func (s *ApplicationService) CountApplicationsByStatus(ctx context.Context) (map[string]int64, error) {
+	counts, err := s.repo.CountApplicationsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	folded := map[string]int64{StatusOther: 0}
+	for _, status := range Statuses {
+		folded[status] = 0
+	}
+
+	for status, count := range counts {
+		if !slices.Contains(Statuses, status) {
+			status = StatusOther
+		}
+
+		folded[status] += count
+	}
+
+	return folded, nil
 }
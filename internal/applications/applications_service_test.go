@@ -0,0 +1,69 @@
+package applications_test
+
+import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCountApplicationsByStatusFoldsUnknownStatuses(t *testing.T) {
+	repo := applications.NewMemoryApplicationRepository()
+	service := applications.NewApplicationService(repo)
+	owner := uuid.NewString()
+
+	insert(t, repo, owner, "Acme", ptr("Applied"))
+	insert(t, repo, owner, "Globex", ptr("Ghosted "+uuid.NewString()))
+	insert(t, repo, owner, "Initech", ptr("applied"))
+
+	counts, err := service.CountApplicationsByStatus(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(counts) != len(applications.Statuses)+1 {
+		t.Errorf("expected one count per status and %q, got %v", applications.StatusOther, counts)
+	}
+
+	if counts["Applied"] != 1 || counts[applications.StatusOther] != 2 || counts["Offer"] != 0 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+func TestBatchVersionsAreMatchedByUUID(t *testing.T) {
+	ctx := context.Background()
+	repo := applications.NewMemoryApplicationRepository()
+	service := applications.NewApplicationService(repo)
+	owner := uuid.NewString()
+
+	insert(t, repo, owner, "Acme", ptr("Applied"))
+	id := find(t, repo, owner, nil)[0].Id.String()
+
+	err := service.BatchUpdateStatusApplications(ctx, owner, &applications.BatchUpdateStatusDto{
+		ApplicationIds: []string{id},
+		Status:         "Offer",
+		Versions:       map[string]int64{strings.ToUpper(id): 7},
+	})
+	assertStatus(t, err, http.StatusPreconditionFailed)
+
+	err = service.BatchUpdateStatusApplications(ctx, owner, &applications.BatchUpdateStatusDto{
+		ApplicationIds: []string{id},
+		Status:         "Offer",
+		Versions:       map[string]int64{uuid.NewString(): 1},
+	})
+	assertStatus(t, err, http.StatusBadRequest)
+}
+
+func TestNonUUIDApplicationIdIsNotFound(t *testing.T) {
+	ctx := context.Background()
+	service := applications.NewApplicationService(applications.NewMemoryApplicationRepository())
+	owner := uuid.NewString()
+
+	_, err := service.PatchApplication(ctx, &applications.ApplicationPatch{}, owner, "not-a-uuid", nil)
+	assertStatus(t, err, http.StatusNotFound)
+
+	assertStatus(t, service.DeleteApplications(ctx, owner, "not-a-uuid", nil), http.StatusNotFound)
+}
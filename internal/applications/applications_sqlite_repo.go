@@ -0,0 +1,470 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteApplicationRepository is the SQLite Repository.
+type SQLiteApplicationRepository struct {
+	db       *sql.DB
+	timeouts storage.Timeouts
+}
+
+func NewSQLiteApplicationRepository(db *sql.DB, timeouts storage.Timeouts) *SQLiteApplicationRepository {
+	return &SQLiteApplicationRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+const sqliteApplicationColumns = `
+	id,
+	user_id,
+	company_name,
+	position_title,
+	job_url,
+	salary_range,
+	location,
+	status,
+	notes,
+	applied_date,
+	created_at,
+	updated_at,
+	deleted_at,
+	version
+`
+
+func (r *SQLiteApplicationRepository) InsertApplication(ctx context.Context, req *CreateApplicationDto, userId string) error {
+	createQuery := `
+		insert into applications (
+			id,
+			user_id,
+			company_name,
+			position_title,
+			job_url,
+			salary_range,
+			location,
+			status,
+			notes,
+			applied_date,
+			created_at
+		) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		createQuery,
+		uuid.NewString(),
+		userId,
+		req.CompanyName,
+		req.PositionTitle,
+		req.JobUrl,
+		req.SalaryRange,
+		req.Location,
+		req.Status,
+		req.Notes,
+		storage.SQLiteNullTime(req.AppliedDate),
+		storage.SQLiteTime(time.Now()),
+	)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteApplicationRepository) FindApplicationsById(ctx context.Context, userId string, queryParams ApplicationQueryParams) ([]Application, error) {
+	fetchQuery := `select ` + sqliteApplicationColumns + ` from applications where user_id = $1 and deleted_at is null`
+
+	args := []any{userId}
+
+	if queryParams.Status != nil {
+		fetchQuery += " and status = $2"
+		args = append(args, *queryParams.Status)
+	}
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	return r.query(ctx, fetchQuery, args...)
+}
+
+func (r *SQLiteApplicationRepository) FindAllApplicationsByUserId(ctx context.Context, userId string) ([]Application, error) {
+	fetchQuery := `select ` + sqliteApplicationColumns + ` from applications where user_id = $1 order by created_at`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	return r.query(ctx, fetchQuery, userId)
+}
+
+func (r *SQLiteApplicationRepository) FindApplicationById(ctx context.Context, userId, applicationId string) (*Application, error) {
+	fetchQuery := `select ` + sqliteApplicationColumns + ` from applications where id = $1 and user_id = $2 and deleted_at is null`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	applications, err := r.query(ctx, fetchQuery, applicationId, userId)
+	if err != nil {
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	if len(applications) == 0 {
+		return nil, appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return &applications[0], nil
+}
+
+func (r *SQLiteApplicationRepository) DeleteApplications(ctx context.Context, userId, applicationId string, version *int64) error {
+	updateQuery := `
+		update applications
+		set deleted_at = $1, version = version + 1
+		where id = $2 and user_id = $3 and deleted_at is null
+	`
+	args := []any{storage.SQLiteTime(time.Now()), applicationId, userId}
+
+	if version != nil {
+		updateQuery += " and version = $4"
+		args = append(args, *version)
+	}
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, updateQuery, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return r.requireUpdated(ctx, result, userId, applicationId, version)
+}
+
+func (r *SQLiteApplicationRepository) UpdateApplications(ctx context.Context, userId, applicationId string, version *int64, patch *ApplicationPatch) (int64, error) {
+	query := "update applications set updated_at = $1, version = version + 1"
+	args := []any{storage.SQLiteTime(time.Now())}
+	body := &patch.Set
+
+	set := func(column string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" , %s = $%d", column, len(args))
+	}
+
+	if body.CompanyName != nil {
+		set("company_name", *body.CompanyName)
+	}
+	if body.PositionTitle != nil {
+		set("position_title", *body.PositionTitle)
+	}
+	if body.JobUrl != nil {
+		set("job_url", *body.JobUrl)
+	}
+	if body.SalaryRange != nil {
+		set("salary_range", *body.SalaryRange)
+	}
+	if body.Location != nil {
+		set("location", *body.Location)
+	}
+	if body.Status != nil {
+		set("status", *body.Status)
+	}
+	if body.Notes != nil {
+		set("notes", *body.Notes)
+	}
+	if body.AppliedDate != nil {
+		set("applied_date", storage.SQLiteTime(*body.AppliedDate))
+	}
+
+	for _, field := range patch.Clear {
+		if column, ok := nullableColumns[field]; ok {
+			query += fmt.Sprintf(" , %s = null", column)
+		}
+	}
+
+	query += fmt.Sprintf(" where id = $%d and user_id = $%d and deleted_at is null", len(args)+1, len(args)+2)
+	args = append(args, applicationId, userId)
+
+	if version != nil {
+		query += fmt.Sprintf(" and version = $%d", len(args)+1)
+		args = append(args, *version)
+	}
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	query += " returning version"
+
+	var updated int64
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&updated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, r.notUpdated(ctx, userId, applicationId, version)
+		}
+
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return updated, nil
+}
+
+func (r *SQLiteApplicationRepository) BatchDeleteApplications(ctx context.Context, userId string, applicationIds []string, versions map[string]int64) error {
+	if len(applicationIds) == 0 {
+		return appError.NewBadRequestError("No application IDs provided")
+	}
+
+	query := `
+		update applications
+		set deleted_at = $1, version = version + 1
+		where user_id = $2 and deleted_at is null and id in ` + storage.SQLiteIn(3, len(applicationIds))
+
+	args := []any{storage.SQLiteTime(time.Now()), userId}
+	for _, id := range applicationIds {
+		args = append(args, id)
+	}
+
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	return r.batch(ctx, userId, applicationIds, versions, "No applications found to delete", query, args...)
+}
+
+func (r *SQLiteApplicationRepository) BatchUpdateStatusApplications(ctx context.Context, userId string, applicationIds []string, status string, versions map[string]int64) error {
+	if len(applicationIds) == 0 {
+		return appError.NewBadRequestError("No application IDs provided")
+	}
+
+	query := `
+		update applications
+		set status = $1, updated_at = $2, version = version + 1
+		where user_id = $3 and deleted_at is null and id in ` + storage.SQLiteIn(4, len(applicationIds))
+
+	args := []any{status, storage.SQLiteTime(time.Now()), userId}
+	for _, id := range applicationIds {
+		args = append(args, id)
+	}
+
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	return r.batch(ctx, userId, applicationIds, versions, "No applications found to update", query, args...)
+}
+
+func (r *SQLiteApplicationRepository) batch(ctx context.Context, userId string, applicationIds []string, versions map[string]int64, notFound, query string, args ...any) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	if err := checkSQLiteVersions(ctx, tx, userId, applicationIds, versions); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if err := requireAffected(result, notFound); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteApplicationRepository) CountApplicationsByStatus(ctx context.Context) (map[string]int64, error) {
+	countQuery := `
+		select coalesce(status, ''), count(*)
+		from applications
+		where deleted_at is null
+		group by 1
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, countQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+
+	for rows.Next() {
+		var status string
+		var count int64
+
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (r *SQLiteApplicationRepository) query(ctx context.Context, query string, args ...any) ([]Application, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := []Application{}
+
+	for rows.Next() {
+		var app Application
+
+		err := rows.Scan(
+			&app.Id,
+			&app.UserId,
+			&app.CompanyName,
+			&app.PositionTitle,
+			&app.JobUrl,
+			&app.SalaryRange,
+			&app.Location,
+			&app.Status,
+			&app.Notes,
+			&app.AppliedDate,
+			&app.CreatedAt,
+			&app.UpdatedAt,
+			&app.DeletedAt,
+			&app.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		applications = append(applications, app)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func checkSQLiteVersions(ctx context.Context, tx *sql.Tx, userId string, applicationIds []string, versions map[string]int64) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	versionQuery := `
+		select id, version
+		from applications
+		where user_id = $1 and deleted_at is null and id in ` + storage.SQLiteIn(2, len(applicationIds))
+
+	args := []any{userId}
+	for _, id := range applicationIds {
+		args = append(args, id)
+	}
+
+	rows, err := tx.QueryContext(ctx, versionQuery, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer rows.Close()
+
+	seen := 0
+	for rows.Next() {
+		var id string
+		var version int64
+
+		if err := rows.Scan(&id, &version); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		expected, ok := versions[id]
+		if !ok {
+			continue
+		}
+
+		if expected != version {
+			return errVersionMismatch()
+		}
+		seen++
+	}
+
+	if err := rows.Err(); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if seen < len(versions) {
+		return appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
+
+// requireUpdated tells a missing application from one with another version.
+func (r *SQLiteApplicationRepository) requireUpdated(ctx context.Context, result sql.Result, userId, applicationId string, version *int64) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if affected > 0 {
+		return nil
+	}
+
+	return r.notUpdated(ctx, userId, applicationId, version)
+}
+
+func (r *SQLiteApplicationRepository) notUpdated(ctx context.Context, userId, applicationId string, version *int64) error {
+	notFound := appError.NewNotFoundErr("Application not found").WithCode(appError.CodeApplicationNotFound)
+	if version == nil {
+		return notFound
+	}
+
+	existsQuery := `select exists (select 1 from applications where id = $1 and user_id = $2 and deleted_at is null)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, existsQuery, applicationId, userId).Scan(&exists); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if exists {
+		return errVersionMismatch()
+	}
+
+	return notFound
+}
+
+func requireAffected(result sql.Result, notFound string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if affected == 0 {
+		return appError.NewNotFoundErr(notFound).WithCode(appError.CodeApplicationNotFound)
+	}
+
+	return nil
+}
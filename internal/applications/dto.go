@@ -27,6 +27,15 @@ type UpdateApplicationDto struct {
 	AppliedDate   *time.Time `json:"appliedDate" validate:"omitempty"`
 }
 
+// ApplicationPatch is a change to an application: the fields given in Set,
+// and the nullable fields named in Clear set to null. PUT only ever sets,
+// while PATCH decodes a merge patch with ParseMergePatch.
+type ApplicationPatch struct {
+	Set UpdateApplicationDto
+	// Clear holds JSON names of nullableColumns.
+	Clear []string
+}
+
 type ApplicationOptionQueryParams struct {
 	StatusOption bool `json:"statusOption"`
 }
@@ -37,9 +46,13 @@ type ApplicationQueryParams struct {
 
 type BatchDeleteDto struct {
 	ApplicationIds []string `json:"applicationIds" validate:"required,min=1"`
+	// Versions optionally maps application ids to the version the client
+	// last saw.
+	Versions map[string]int64 `json:"versions,omitempty"`
 }
 
 type BatchUpdateStatusDto struct {
-	ApplicationIds []string `json:"applicationIds" validate:"required,min=1"`
-	Status         string   `json:"status" validate:"required,min=2,max=50"`
+	ApplicationIds []string         `json:"applicationIds" validate:"required,min=1"`
+	Status         string           `json:"status" validate:"required,min=2,max=50"`
+	Versions       map[string]int64 `json:"versions,omitempty"`
 }
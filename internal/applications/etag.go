@@ -0,0 +1,54 @@
+package applications
+
+import (
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag is the entity tag of an application at version. Versions are only
+// compared per application, so the number alone is enough.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch returns the version an If-Match header asks for. The header
+// is required so that no client overwrites a change by accident; "*" is the
+// explicit way to skip the check and gives nil. Anything else can't match an
+// ETag of ours, so it fails the precondition right away.
+func ParseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, appError.New(
+			errors.New("missing If-Match header"),
+			"If-Match is required, send the ETag of the application or * to skip the check",
+			http.StatusPreconditionRequired,
+		).WithCode(appError.CodePreconditionRequired)
+	}
+
+	if header == "*" {
+		return nil, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil {
+		return nil, errVersionMismatch()
+	}
+
+	return &version, nil
+}
+
+func errVersionMismatch() *appError.AppError {
+	return appError.New(
+		errors.New("application version mismatch"),
+		"Application was changed by another request, reload it and try again",
+		http.StatusPreconditionFailed,
+	).WithCode(appError.CodeApplicationVersionMismatch)
+}
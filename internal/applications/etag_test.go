@@ -0,0 +1,27 @@
+package applications_test
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"net/http"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	version, err := applications.ParseIfMatch(` "3" `)
+	if err != nil || version == nil || *version != 3 {
+		t.Errorf("expected version 3, got %v, %v", version, err)
+	}
+
+	version, err = applications.ParseIfMatch("*")
+	if err != nil || version != nil {
+		t.Errorf("expected * to skip the check, got %v, %v", version, err)
+	}
+
+	_, err = applications.ParseIfMatch("")
+	assertStatus(t, err, http.StatusPreconditionRequired)
+
+	for _, header := range []string{`3`, `W/"3"`, `"abc"`, `"3`} {
+		_, err := applications.ParseIfMatch(header)
+		assertStatus(t, err, http.StatusPreconditionFailed)
+	}
+}
@@ -0,0 +1,71 @@
+package applications
+
+import (
+	"encoding/json"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergePatchContentType is the media type of an RFC 7396 merge patch.
+const MergePatchContentType = "application/merge-patch+json"
+
+// nullableColumns are the fields a patch can clear, by JSON name.
+var nullableColumns = map[string]string{
+	"jobUrl":      "job_url",
+	"salaryRange": "salary_range",
+	"location":    "location",
+	"notes":       "notes",
+	"appliedDate": "applied_date",
+}
+
+// patchFields are the JSON names of UpdateApplicationDto. Null for any
+// other member is ignored, like any other unknown member.
+var patchFields = func() map[string]bool {
+	fields := make(map[string]bool)
+
+	t := reflect.TypeOf(UpdateApplicationDto{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+
+	return fields
+}()
+
+// ParseMergePatch decodes an RFC 7396 merge patch of an application, where
+// a null member clears the field and an absent one leaves it alone. It also
+// returns the fields that were null but can't be cleared, sorted, for the
+// caller to report along with the validation errors of Set.
+func ParseMergePatch(body []byte) (*ApplicationPatch, []string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		// A patch that isn't an object would replace the whole application.
+		return nil, nil, appError.NewBadRequestError("Merge patch must be a JSON object").WithCode(appError.CodeBadRequest)
+	}
+
+	patch := new(ApplicationPatch)
+	if err := json.Unmarshal(body, &patch.Set); err != nil {
+		return nil, nil, err
+	}
+
+	var notNull []string
+
+	for name, value := range members {
+		if string(value) != "null" || !patchFields[name] {
+			continue
+		}
+
+		if _, ok := nullableColumns[name]; ok {
+			patch.Clear = append(patch.Clear, name)
+		} else {
+			notNull = append(notNull, name)
+		}
+	}
+
+	sort.Strings(patch.Clear)
+	sort.Strings(notNull)
+
+	return patch, notNull, nil
+}
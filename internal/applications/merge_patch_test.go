@@ -0,0 +1,38 @@
+package applications_test
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"reflect"
+	"testing"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	patch, notNull, err := applications.ParseMergePatch([]byte(`{
+		"location": "Remote",
+		"jobUrl": null,
+		"notes": null,
+		"companyName": null,
+		"unknown": null
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if patch.Set.Location == nil || *patch.Set.Location != "Remote" || patch.Set.JobUrl != nil {
+		t.Errorf("expected only location to be set, got %+v", patch.Set)
+	}
+
+	if want := []string{"jobUrl", "notes"}; !reflect.DeepEqual(patch.Clear, want) {
+		t.Errorf("expected %v to be cleared, got %v", want, patch.Clear)
+	}
+
+	if want := []string{"companyName"}; !reflect.DeepEqual(notNull, want) {
+		t.Errorf("expected %v to be refused, got %v", want, notNull)
+	}
+
+	for _, body := range []string{`null`, `[]`, `"notes"`, `{"notes": 1}`} {
+		if _, _, err := applications.ParseMergePatch([]byte(body)); err == nil {
+			t.Errorf("expected %s to be refused", body)
+		}
+	}
+}
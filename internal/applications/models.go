@@ -1,6 +1,8 @@
 package applications
 
 import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/storage"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,27 +23,63 @@ type Application struct {
 	CreatedAt     time.Time  `json:"createdAt"`
 	UpdatedAt     *time.Time `json:"updatedAt"`
 	DeletedAt     *time.Time `json:"deletedAt"`
+	// Version goes up with every change, see ETag.
+	Version int64 `json:"version"`
 }
 
+// Statuses are the statuses the client suggests. Any other text is allowed
+// too, but is only counted as StatusOther in the metrics.
+var Statuses = []string{"Wishlist", "Applied", "Interviewing", "Offer", "Rejected"}
+
+const StatusOther = "other"
+
 type ApplicationOptions struct {
 	StatusOption []string `json:"statusOption"`
 }
 
+// Repository stores applications. Every method is scoped to the owning user,
+// and deleted applications are soft-deleted and hidden from everything but
+// FindAllApplicationsByUserId.
+//
+// Changes take the version the client last saw, and fail with 412 when the
+// application has changed since. A nil version or versions skips the check.
+type Repository interface {
+	InsertApplication(ctx context.Context, req *CreateApplicationDto, userId string) error
+	FindApplicationsById(ctx context.Context, userId string, queryParams ApplicationQueryParams) ([]Application, error)
+	FindApplicationById(ctx context.Context, userId, applicationId string) (*Application, error)
+	DeleteApplications(ctx context.Context, userId, applicationId string, version *int64) error
+	// UpdateApplications returns the version the application has after the
+	// update.
+	UpdateApplications(ctx context.Context, userId, applicationId string, version *int64, patch *ApplicationPatch) (int64, error)
+	// The batch methods check the version of every application in versions,
+	// and change none of them when one doesn't match.
+	BatchDeleteApplications(ctx context.Context, userId string, applicationIds []string, versions map[string]int64) error
+	BatchUpdateStatusApplications(ctx context.Context, userId string, applicationIds []string, status string, versions map[string]int64) error
+	FindAllApplicationsByUserId(ctx context.Context, userId string) ([]Application, error)
+	// CountApplicationsByStatus counts the applications of every user that
+	// aren't deleted, for metrics.
+	CountApplicationsByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// ApplicationRepository is the Postgres Repository.
 type ApplicationRepository struct {
-	db *pgxpool.Pool
+	db       *pgxpool.Pool
+	timeouts storage.Timeouts
 }
+
 type ApplicationService struct {
-	repo *ApplicationRepository
+	repo Repository
 }
 
-func NewApplicationService(repo *ApplicationRepository) *ApplicationService {
+func NewApplicationService(repo Repository) *ApplicationService {
 	return &ApplicationService{
 		repo: repo,
 	}
 }
 
-func NewApplicationRepository(db *pgxpool.Pool) *ApplicationRepository {
+func NewApplicationRepository(db *pgxpool.Pool, timeouts storage.Timeouts) *ApplicationRepository {
 	return &ApplicationRepository{
-		db: db,
+		db:       db,
+		timeouts: timeouts,
 	}
 }
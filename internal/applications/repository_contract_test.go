@@ -0,0 +1,335 @@
+package applications_test
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/database/dbtest"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// backend returns a fresh repository and a function that creates users the
+// repository will accept.
+type backend func(t *testing.T) (applications.Repository, func() string)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (applications.Repository, func() string) {
+		return applications.NewMemoryApplicationRepository(), uuid.NewString
+	})
+}
+
+func TestPostgresRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (applications.Repository, func() string) {
+		pool := dbtest.Pool(t)
+		return applications.NewApplicationRepository(pool, storage.DefaultTimeouts), func() string { return dbtest.CreateUser(t, pool) }
+	})
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (applications.Repository, func() string) {
+		db := dbtest.SQLite(t)
+		return applications.NewSQLiteApplicationRepository(db, storage.DefaultTimeouts), func() string { return dbtest.CreateSQLiteUser(t, db) }
+	})
+}
+
+func runRepositoryContract(t *testing.T, newBackend backend) {
+	ctx := context.Background()
+
+	t.Run("insert and find are scoped to the user", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner, other := newUser(), newUser()
+
+		insert(t, repo, owner, "Acme", nil)
+
+		apps := find(t, repo, owner, nil)
+		if len(apps) != 1 || apps[0].CompanyName != "Acme" || apps[0].UserId.String() != owner {
+			t.Fatalf("expected the owner's application, got %+v", apps)
+		}
+
+		if apps := find(t, repo, other, nil); len(apps) != 0 {
+			t.Fatalf("expected no applications for another user, got %d", len(apps))
+		}
+	})
+
+	t.Run("find filters by status", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		insert(t, repo, owner, "Applied Co", ptr("Applied"))
+		insert(t, repo, owner, "Offer Co", ptr("Offer"))
+
+		apps := find(t, repo, owner, ptr("Offer"))
+		if len(apps) != 1 || apps[0].CompanyName != "Offer Co" {
+			t.Fatalf("expected only the offer, got %+v", apps)
+		}
+	})
+
+	t.Run("update changes only the given fields", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		insert(t, repo, owner, "Acme", ptr("Applied"))
+		id := find(t, repo, owner, nil)[0].Id.String()
+
+		if _, err := repo.UpdateApplications(ctx, owner, id, nil, &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{Notes: ptr("call back")}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		app := find(t, repo, owner, nil)[0]
+		if app.CompanyName != "Acme" || app.Status == nil || *app.Status != "Applied" {
+			t.Errorf("expected untouched fields to stay, got %+v", app)
+		}
+		if app.Notes == nil || *app.Notes != "call back" || app.UpdatedAt == nil {
+			t.Errorf("expected notes and updatedAt to be set, got %+v", app)
+		}
+
+		_, err := repo.UpdateApplications(ctx, newUser(), id, nil, &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{Notes: ptr("mine now")}})
+		assertStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("patch clears nullable fields", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		insert(t, repo, owner, "Acme", nil)
+		id := find(t, repo, owner, nil)[0].Id.String()
+
+		_, err := repo.UpdateApplications(ctx, owner, id, nil, &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{
+			JobUrl:   ptr("https://example.com/job"),
+			Location: ptr("Remote"),
+			Notes:    ptr("call back"),
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = repo.UpdateApplications(ctx, owner, id, nil, &applications.ApplicationPatch{
+			Set:   applications.UpdateApplicationDto{Location: ptr("Jakarta")},
+			Clear: []string{"jobUrl", "notes"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		app := find(t, repo, owner, nil)[0]
+		if app.JobUrl != nil || app.Notes != nil {
+			t.Errorf("expected jobUrl and notes to be cleared, got %+v", app)
+		}
+		if app.Location == nil || *app.Location != "Jakarta" || app.CompanyName != "Acme" {
+			t.Errorf("expected the other fields to stay, got %+v", app)
+		}
+	})
+
+	t.Run("delete is soft and scoped to the user", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		insert(t, repo, owner, "Acme", nil)
+		id := find(t, repo, owner, nil)[0].Id.String()
+
+		assertStatus(t, repo.DeleteApplications(ctx, newUser(), id, nil), http.StatusNotFound)
+
+		if err := repo.DeleteApplications(ctx, owner, id, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if apps := find(t, repo, owner, nil); len(apps) != 0 {
+			t.Fatalf("expected deleted application to be hidden, got %d", len(apps))
+		}
+
+		all, err := repo.FindAllApplicationsByUserId(ctx, owner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(all) != 1 || all[0].DeletedAt == nil {
+			t.Fatalf("expected the deleted application in the export, got %+v", all)
+		}
+
+		assertStatus(t, repo.DeleteApplications(ctx, owner, id, nil), http.StatusNotFound)
+		_, err = repo.UpdateApplications(ctx, owner, id, nil, &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{Notes: ptr("x")}})
+		assertStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("batch operations skip other users and deleted applications", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner, other := newUser(), newUser()
+
+		insert(t, repo, owner, "First", nil)
+		insert(t, repo, owner, "Second", nil)
+		insert(t, repo, other, "Theirs", nil)
+
+		var ids []string
+		for _, app := range find(t, repo, owner, nil) {
+			ids = append(ids, app.Id.String())
+		}
+		theirs := find(t, repo, other, nil)[0].Id.String()
+
+		if err := repo.BatchUpdateStatusApplications(ctx, owner, append(ids, theirs), "Rejected", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if apps := find(t, repo, owner, ptr("Rejected")); len(apps) != 2 {
+			t.Fatalf("expected both applications to be rejected, got %d", len(apps))
+		}
+		if app := find(t, repo, other, nil)[0]; app.Status != nil && *app.Status == "Rejected" {
+			t.Fatal("expected another user's application to be untouched")
+		}
+
+		if err := repo.BatchDeleteApplications(ctx, owner, append(ids, theirs), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if apps := find(t, repo, owner, nil); len(apps) != 0 {
+			t.Fatalf("expected all applications to be deleted, got %d", len(apps))
+		}
+		if apps := find(t, repo, other, nil); len(apps) != 1 {
+			t.Fatal("expected another user's application to survive")
+		}
+
+		assertStatus(t, repo.BatchDeleteApplications(ctx, owner, ids, nil), http.StatusNotFound)
+		assertStatus(t, repo.BatchUpdateStatusApplications(ctx, owner, ids, "Offer", nil), http.StatusNotFound)
+		assertStatus(t, repo.BatchDeleteApplications(ctx, owner, nil, nil), http.StatusBadRequest)
+	})
+
+	t.Run("changes check the version and bump it", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		insert(t, repo, owner, "Acme", nil)
+		id := find(t, repo, owner, nil)[0].Id.String()
+
+		app, err := repo.FindApplicationById(ctx, owner, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.CompanyName != "Acme" || app.Version != 1 {
+			t.Fatalf("expected version 1 of the application, got %+v", app)
+		}
+
+		_, err = repo.FindApplicationById(ctx, newUser(), id)
+		assertStatus(t, err, http.StatusNotFound)
+
+		version, err := repo.UpdateApplications(ctx, owner, id, ptr[int64](1), &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{Notes: ptr("first")}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if version != 2 {
+			t.Errorf("expected the update to return version 2, got %d", version)
+		}
+
+		_, err = repo.UpdateApplications(ctx, owner, id, ptr[int64](1), &applications.ApplicationPatch{Set: applications.UpdateApplicationDto{Notes: ptr("stale")}})
+		assertStatus(t, err, http.StatusPreconditionFailed)
+
+		if app := find(t, repo, owner, nil)[0]; app.Version != 2 || *app.Notes != "first" {
+			t.Fatalf("expected the stale update to change nothing, got %+v", app)
+		}
+
+		assertStatus(t, repo.BatchUpdateStatusApplications(ctx, owner, []string{id}, "Offer", map[string]int64{id: 1}), http.StatusPreconditionFailed)
+		if err := repo.BatchUpdateStatusApplications(ctx, owner, []string{id}, "Offer", map[string]int64{id: 2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, repo.DeleteApplications(ctx, owner, id, ptr[int64](2)), http.StatusPreconditionFailed)
+		assertStatus(t, repo.BatchDeleteApplications(ctx, owner, []string{id}, map[string]int64{id: 2}), http.StatusPreconditionFailed)
+		if err := repo.DeleteApplications(ctx, owner, id, ptr[int64](3)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, repo.DeleteApplications(ctx, owner, id, ptr[int64](4)), http.StatusNotFound)
+		_, err = repo.FindApplicationById(ctx, owner, id)
+		assertStatus(t, err, http.StatusNotFound)
+
+		insert(t, repo, owner, "Live", nil)
+		live := find(t, repo, owner, nil)[0].Id.String()
+		other := newUser()
+		insert(t, repo, other, "Theirs", nil)
+		theirs := find(t, repo, other, nil)[0].Id.String()
+
+		for name, gone := range map[string]string{"deleted": id, "someone else's": theirs, "unknown": uuid.NewString()} {
+			ids := []string{live, gone}
+			versions := map[string]int64{live: 1, gone: 1}
+
+			if err := repo.BatchUpdateStatusApplications(ctx, owner, ids, "Offer", versions); err == nil {
+				t.Errorf("expected a batch update with a %s application to fail", name)
+			} else {
+				assertStatus(t, err, http.StatusNotFound)
+			}
+			if err := repo.BatchDeleteApplications(ctx, owner, ids, versions); err == nil {
+				t.Errorf("expected a batch delete with a %s application to fail", name)
+			} else {
+				assertStatus(t, err, http.StatusNotFound)
+			}
+		}
+
+		if app := find(t, repo, owner, nil)[0]; app.Version != 1 {
+			t.Fatalf("expected the failed batches to change nothing, got %+v", app)
+		}
+	})
+
+	t.Run("count by status skips deleted applications", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+		status := "Counted " + uuid.NewString()
+
+		insert(t, repo, owner, "Kept", ptr(status))
+		insert(t, repo, owner, "Deleted", ptr(status))
+
+		if err := repo.DeleteApplications(ctx, owner, find(t, repo, owner, nil)[1].Id.String(), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		counts, err := repo.CountApplicationsByStatus(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if counts[status] != 1 {
+			t.Errorf("expected 1 application with status %q, got %d", status, counts[status])
+		}
+	})
+}
+
+func insert(t *testing.T, repo applications.Repository, userId, company string, status *string) {
+	t.Helper()
+
+	err := repo.InsertApplication(context.Background(), &applications.CreateApplicationDto{
+		CompanyName:   company,
+		PositionTitle: "Engineer",
+		Status:        status,
+	}, userId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func find(t *testing.T, repo applications.Repository, userId string, status *string) []applications.Application {
+	t.Helper()
+
+	apps, err := repo.FindApplicationsById(context.Background(), userId, applications.ApplicationQueryParams{Status: status})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return apps
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected an AppError with status %d, got %v", status, err)
+	}
+
+	if appErr.StatusCode != status {
+		t.Errorf("expected status %d, got %d", status, appErr.StatusCode)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
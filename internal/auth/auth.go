@@ -1,33 +1,101 @@
 package auth
 
 import (
-	"hafiztri123/hv1-job-tracker/internal/utils"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(id, email string) (string, error) {
+const TokenTTL = 24 * time.Hour
 
+func GenerateToken(id, email, sessionId string) (string, error) {
+
+	claims := &Claims{
+		UserId:    id,
+		Email:     email,
+		SessionId: sessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   id,
+		},
+	}
+
+	return signClaims(claims)
+}
+
+// GenerateChallengeToken issues the short-lived token returned by a password
+// login when the user has two-factor authentication enabled. It can only be
+// exchanged for a session token and is rejected by AuthMiddleware. Its jti
+// lets the verification spend it after a single attempt.
+func GenerateChallengeToken(id, email string) (string, error) {
 	claims := &Claims{
-		UserId: id,
-		Email:  email,
+		UserId:  id,
+		Email:   email,
+		Purpose: PurposeTwoFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   id,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret()))
+	return signClaims(claims)
+}
+
+func ParseChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if claims.Purpose != PurposeTwoFactor {
+		return nil, errors.New("not a two-factor challenge token")
+	}
+
+	if _, err := uuid.Parse(claims.ID); err != nil {
+		return nil, errors.New("two-factor challenge token has no id")
+	}
+
+	return claims, nil
+}
+
+// SignClaims signs arbitrary claims with the server's token key. It is used
+// for short-lived flow state that has to survive a browser redirect.
+func SignClaims(claims jwt.Claims) (string, error) {
+	return signClaims(claims)
+}
+
+// ParseSignedClaims verifies a token produced by SignClaims into claims.
+func ParseSignedClaims(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return jwt.ErrTokenInvalidClaims
+	}
+
+	return nil
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	return currentKeySet().sign(claims)
+}
+
+func parseClaims(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := ParseSignedClaims(tokenString, claims); err != nil {
+		return nil, err
 	}
 
-	return tokenString, nil
+	return claims, nil
 }
 
-func secret() string {
-	return utils.GetEnv("JWT_SECRET", "secret")
+func keyFunc(t *jwt.Token) (any, error) {
+	return currentKeySet().keyFunc(t)
 }
@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultSecret = "secret"
+	legacyKeyID   = "hs256"
+)
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// KeySet holds the key tokens are signed with and every key that is still
+// accepted for verification. After a rotation the previous key stays in the
+// set, verify-only, so tokens it signed keep working until they expire.
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+
+	usesDefaultSecret bool
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	keysMu     sync.RWMutex
+	defaultSet *KeySet
+)
+
+// SetKeySet replaces the keys used by GenerateToken and the middleware.
+func SetKeySet(ks *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	defaultSet = ks
+}
+
+func currentKeySet() *KeySet {
+	keysMu.RLock()
+	ks := defaultSet
+	keysMu.RUnlock()
+
+	if ks != nil {
+		return ks
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	if defaultSet == nil {
+		loaded, err := LoadKeySet(KeyConfig{})
+		if err != nil {
+			panic(fmt.Sprintf("failed to load jwt keys: %v", err))
+		}
+		defaultSet = loaded
+	}
+
+	return defaultSet
+}
+
+// KeyConfig says where LoadKeySet finds the signing keys.
+type KeyConfig struct {
+	// Dir holds one PEM file per key, named <kid>.pem.
+	Dir        string
+	PrivateKey string
+	KeyID      string
+	ActiveKID  string
+	Secret     string
+}
+
+// LoadKeySet reads asymmetric keys from cfg.Dir or cfg.PrivateKey and signs
+// with cfg.ActiveKID. Without either it falls back to HS256 with cfg.Secret,
+// or the default secret when that's empty too. When both are configured the
+// secret is kept verify-only so HS256 tokens survive the switch.
+func LoadKeySet(cfg KeyConfig) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*signingKey{}}
+
+	if cfg.Dir != "" {
+		if err := ks.loadDir(cfg.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.PrivateKey != "" {
+		kid := cfg.KeyID
+		if kid == "" {
+			kid = "env"
+		}
+
+		key, err := parseKey(kid, []byte(cfg.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("jwt private key: %w", err)
+		}
+		ks.keys[kid] = key
+	}
+
+	if len(ks.keys) == 0 {
+		secret := cfg.Secret
+		if secret == "" {
+			secret = defaultSecret
+		}
+
+		ks.usesDefaultSecret = secret == defaultSecret
+		ks.active = hmacKey(secret)
+		ks.keys[legacyKeyID] = ks.active
+
+		return ks, nil
+	}
+
+	if err := ks.selectActive(cfg.ActiveKID); err != nil {
+		return nil, err
+	}
+
+	if cfg.Secret != "" {
+		ks.keys[legacyKeyID] = hmacKey(cfg.Secret)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) loadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		ks.keys[kid] = key
+	}
+
+	return nil
+}
+
+// selectActive picks the configured kid, or the last signing key by name so
+// that date-named key files rotate without touching the config.
+func (ks *KeySet) selectActive(kid string) error {
+	if kid != "" {
+		key, ok := ks.keys[kid]
+		if !ok || key.private == nil {
+			return fmt.Errorf("no private key found for JWT_ACTIVE_KID %q", kid)
+		}
+		ks.active = key
+		return nil
+	}
+
+	kids := make([]string, 0, len(ks.keys))
+	for kid, key := range ks.keys {
+		if key.private != nil {
+			kids = append(kids, kid)
+		}
+	}
+
+	if len(kids) == 0 {
+		return errors.New("jwt keys contain no private key to sign with")
+	}
+
+	sort.Strings(kids)
+	ks.active = ks.keys[kids[len(kids)-1]]
+
+	return nil
+}
+
+// UsesDefaultSecret reports whether tokens are signed with the built-in
+// development secret, which must never happen in production.
+func (ks *KeySet) UsesDefaultSecret() bool {
+	return ks.usesDefaultSecret
+}
+
+func (ks *KeySet) ActiveKeyID() string {
+	return ks.active.kid
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.kid != legacyKeyID {
+		token.Header["kid"] = ks.active.kid
+	}
+
+	return token.SignedString(ks.active.private)
+}
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyKeyID
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return key.public, nil
+}
+
+// JWKS returns the public half of every asymmetric key. HMAC secrets are
+// never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+
+	for _, key := range ks.keys {
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
+
+func CurrentJWKS() JWKS {
+	return currentKeySet().JWKS()
+}
+
+func hmacKey(secret string) *signingKey {
+	return &signingKey{
+		kid:     legacyKeyID,
+		method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+// parseKey accepts PKCS#8 or PKCS#1 private keys and PKIX public keys. A
+// public key on its own is enough to keep verifying a retired key.
+func parseKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, private: key, public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, public: key}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, private: key, public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, public: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeEd25519Key(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2026-01")
+
+	t.Cleanup(func() { SetKeySet(nil) })
+
+	before, err := LoadKeySet(KeyConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SetKeySet(before)
+
+	oldToken, err := GenerateToken("user-1", "user@example.com", "session-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeEd25519Key(t, dir, "2026-02")
+
+	after, err := LoadKeySet(KeyConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SetKeySet(after)
+
+	if after.ActiveKeyID() != "2026-02" {
+		t.Errorf("expected newest key to be active, got %s", after.ActiveKeyID())
+	}
+
+	if _, err := parseClaims(oldToken); err != nil {
+		t.Errorf("expected token signed with the retired key to verify, got %v", err)
+	}
+
+	newToken, err := GenerateToken("user-1", "user@example.com", "session-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	SetKeySet(before)
+	if _, err := parseClaims(newToken); err == nil {
+		t.Error("expected token signed with an unknown key to be rejected")
+	}
+
+	if jwks := after.JWKS(); len(jwks.Keys) != 2 {
+		t.Errorf("expected 2 published keys, got %d", len(jwks.Keys))
+	}
+}
+
+func TestDefaultSecretIsFlagged(t *testing.T) {
+	ks, err := LoadKeySet(KeyConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ks.UsesDefaultSecret() {
+		t.Error("expected default secret to be flagged")
+	}
+
+	if len(ks.JWKS().Keys) != 0 {
+		t.Error("expected hmac secret not to be published")
+	}
+}
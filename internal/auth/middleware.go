@@ -1,50 +1,182 @@
 package auth
 
 import (
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/i18n"
 	"hafiztri123/hv1-job-tracker/internal/utils"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(c *fiber.Ctx) error {
-	auth := c.Get("Authorization")
+type Middleware struct {
+	tokens   TokenAuthenticator
+	sessions SessionValidator
+	cookies  *CookieConfig
+}
 
-	if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
+func NewMiddleware(tokens TokenAuthenticator, sessions SessionValidator, cookies *CookieConfig) *Middleware {
+	return &Middleware{
+		tokens:   tokens,
+		sessions: sessions,
+		cookies:  cookies,
+	}
+}
+
+// AuthMiddleware only accepts session tokens. Account management routes use
+// it so that a leaked personal access token can't be used to take over the
+// account.
+func (m *Middleware) AuthMiddleware(c *fiber.Ctx) error {
+	if tokenString, ok := m.cookieToken(c); ok {
+		return m.authenticateCookie(c, tokenString)
+	}
+
+	tokenString, ok := bearerToken(c)
+	if !ok || strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
 		slog.Error("Authorization")
-		return utils.NewResponse(
-			c,
-			utils.WithStatus(http.StatusUnauthorized),
-			utils.WithMessage("Unauthorized"),
-		)
+		return unauthorized(c)
 	}
 
-	tokenString := strings.TrimPrefix(auth, "Bearer ")
+	return m.authenticateSession(c, tokenString)
+}
 
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
+// ScopedAuthMiddleware accepts session tokens and personal access tokens.
+// Personal access tokens need readScope for safe methods and writeScope for
+// everything else.
+func (m *Middleware) ScopedAuthMiddleware(readScope, writeScope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if tokenString, ok := m.cookieToken(c); ok {
+			return m.authenticateCookie(c, tokenString)
 		}
 
-		return []byte(secret()), nil
-	})
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			slog.Error("Authorization")
+			return unauthorized(c)
+		}
 
-	if err != nil || !token.Valid {
+		if !strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+			return m.authenticateSession(c, tokenString)
+		}
+
+		principal, err := m.tokens.AuthenticateToken(c.UserContext(), tokenString)
+		if err != nil {
+			return rejected(c, err)
+		}
+
+		scope := writeScope
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = readScope
+		}
+
+		if !principal.HasScope(scope) {
+			// Parameterised, so it is translated here rather than by NewProblem.
+			missing, _ := utils.Translator(c).T(i18n.TokenMissingScope, scope)
+			return utils.NewResponse(
+				c,
+				utils.WithMessage("Forbidden"),
+				utils.WithStatus(http.StatusForbidden),
+				utils.WithError(missing),
+				utils.WithCode(appError.CodeMissingScope),
+			)
+		}
+
+		setPrincipal(c, principal)
+
+		return c.Next()
+	}
+}
+
+// authenticateCookie is authenticateSession plus the CSRF check that cookie
+// credentials need, since the browser attaches them to cross-site requests.
+func (m *Middleware) authenticateCookie(c *fiber.Ctx, tokenString string) error {
+	if !validCSRF(c) {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Forbidden"),
+			utils.WithStatus(http.StatusForbidden),
+			utils.WithError("missing or invalid CSRF token"),
+			utils.WithCode(appError.CodeInvalidCSRF),
+		)
+	}
+
+	return m.authenticateSession(c, tokenString)
+}
+
+func (m *Middleware) authenticateSession(c *fiber.Ctx, tokenString string) error {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithMessage("Unauthorized"),
 			utils.WithStatus(http.StatusUnauthorized),
 			utils.WithError(err.Error()),
+			utils.WithCode(appError.CodeInvalidToken),
 		)
 	}
 
-	c.Locals("userId", claims.UserId)
-	c.Locals("email", claims.Email)
+	if claims.Purpose != "" || claims.UserId == "" || claims.SessionId == "" {
+		return unauthorized(c)
+	}
+
+	if err := m.sessions.ValidateSession(c.UserContext(), claims.SessionId, claims.UserId); err != nil {
+		return rejected(c, err)
+	}
+
+	setPrincipal(c, &Principal{UserId: claims.UserId, Email: claims.Email})
+	c.Locals("sessionId", claims.SessionId)
 
 	return c.Next()
+}
+
+func setPrincipal(c *fiber.Ctx, principal *Principal) {
+	c.Locals("userId", principal.UserId)
+	c.Locals("email", principal.Email)
+	c.Locals("principal", principal)
+}
+
+// cookieToken returns the session cookie in cookie mode. An explicit
+// Authorization header wins, so API clients keep working unchanged.
+func (m *Middleware) cookieToken(c *fiber.Ctx) (string, bool) {
+	if m.cookies == nil || !m.cookies.Enabled || c.Get("Authorization") != "" {
+		return "", false
+	}
+
+	token := c.Cookies(SessionCookieName)
+
+	return token, token != ""
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	auth := c.Get("Authorization")
+
+	if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(auth, "Bearer "), true
+}
+
+func unauthorized(c *fiber.Ctx) error {
+	return utils.NewResponse(
+		c,
+		utils.WithStatus(http.StatusUnauthorized),
+		utils.WithMessage("Unauthorized"),
+	)
+}
+
+// rejected answers a failed token or session lookup. Only a lookup that
+// found the credentials invalid is a 401. One that failed, like a database
+// error or a timeout, says nothing about the credentials, so it goes to the
+// error handler instead.
+func rejected(c *fiber.Ctx, err error) error {
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) && appErr.StatusCode < http.StatusInternalServerError {
+		return unauthorized(c)
+	}
 
+	return err
 }
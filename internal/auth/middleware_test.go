@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type fakeSessions map[string]bool
+
+func (f fakeSessions) ValidateSession(_ context.Context, sessionId, _ string) error {
+	if sessionId == "unavailable" {
+		return appError.WrapInternalServerError(errors.New("database is down"))
+	}
+
+	if f[sessionId] {
+		return nil
+	}
+
+	return appError.ErrUnauthorized
+}
+
+type fakeTokens map[string]*Principal
+
+func (f fakeTokens) AuthenticateToken(_ context.Context, rawToken string) (*Principal, error) {
+	if p, ok := f[rawToken]; ok {
+		return p, nil
+	}
+
+	return nil, appError.ErrUnauthorized
+}
+
+func TestScopedAuthMiddleware(t *testing.T) {
+	readOnly := PersonalAccessTokenPrefix + "read"
+	writeOnly := PersonalAccessTokenPrefix + "write"
+	readWrite := PersonalAccessTokenPrefix + "read-write"
+
+	m := NewMiddleware(fakeTokens{
+		readOnly:  {UserId: "user-1", Scopes: []string{ScopeReadApplications}, PersonalAccessToken: true},
+		writeOnly: {UserId: "user-1", Scopes: []string{ScopeWriteApplications}, PersonalAccessToken: true},
+		readWrite: {UserId: "user-1", Scopes: []string{ScopeReadApplications, ScopeWriteApplications}, PersonalAccessToken: true},
+	}, fakeSessions{"active": true}, nil)
+
+	app := fiber.New()
+	app.Use(m.ScopedAuthMiddleware(ScopeReadApplications, ScopeWriteApplications))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(c.Locals("userId").(string)) })
+	app.Post("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusCreated) })
+	app.Delete("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusNoContent) })
+
+	session, err := GenerateToken("user-2", "user@example.com", "active")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	revoked, err := GenerateToken("user-2", "user@example.com", "revoked")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unavailable, err := GenerateToken("user-2", "user@example.com", "unavailable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		token  string
+		want   int
+	}{
+		{"token with read scope can read", http.MethodGet, readOnly, http.StatusOK},
+		{"token with read scope can send HEAD", http.MethodHead, readOnly, http.StatusOK},
+		{"token without write scope can't write", http.MethodPost, readOnly, http.StatusForbidden},
+		{"token without write scope can't delete", http.MethodDelete, readOnly, http.StatusForbidden},
+		{"token without read scope can't read", http.MethodGet, writeOnly, http.StatusForbidden},
+		{"token with write scope can write", http.MethodPost, writeOnly, http.StatusCreated},
+		{"token with both scopes can read", http.MethodGet, readWrite, http.StatusOK},
+		{"token with both scopes can delete", http.MethodDelete, readWrite, http.StatusNoContent},
+		{"unknown token is rejected", http.MethodGet, PersonalAccessTokenPrefix + "nope", http.StatusUnauthorized},
+		{"session token can write", http.MethodPost, session, http.StatusCreated},
+		{"revoked session is rejected", http.MethodGet, revoked, http.StatusUnauthorized},
+		{"failed session lookup is a server error", http.MethodGet, unavailable, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("expected status %d, got %d", tc.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsPersonalAccessTokens(t *testing.T) {
+	token := PersonalAccessTokenPrefix + "read"
+
+	m := NewMiddleware(fakeTokens{
+		token: {UserId: "user-1", Scopes: Scopes, PersonalAccessToken: true},
+	}, fakeSessions{}, nil)
+
+	app := fiber.New()
+	app.Get("/", m.AuthMiddleware, func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestAuthMiddlewareCookieMode(t *testing.T) {
+	m := NewMiddleware(fakeTokens{}, fakeSessions{"active": true}, &CookieConfig{Enabled: true})
+
+	app := fiber.New()
+	app.Use(m.AuthMiddleware)
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+	app.Post("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	session, err := GenerateToken("user-1", "user@example.com", "active")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		csrf   string
+		want   int
+	}{
+		{"safe method needs no csrf token", http.MethodGet, "", http.StatusOK},
+		{"unsafe method without csrf token is rejected", http.MethodPost, "", http.StatusForbidden},
+		{"unsafe method with wrong csrf token is rejected", http.MethodPost, "wrong", http.StatusForbidden},
+		{"unsafe method with matching csrf token passes", http.MethodPost, "csrf-token", http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: session})
+			req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-token"})
+			if tc.csrf != "" {
+				req.Header.Set(CSRFHeaderName, tc.csrf)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("expected status %d, got %d", tc.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMissingScopeIsTranslated(t *testing.T) {
+	readOnly := PersonalAccessTokenPrefix + "read"
+
+	m := NewMiddleware(fakeTokens{
+		readOnly: {UserId: "user-1", Scopes: []string{ScopeReadApplications}, PersonalAccessToken: true},
+	}, fakeSessions{}, nil)
+
+	app := fiber.New()
+	app.Post("/", m.ScopedAuthMiddleware(ScopeReadApplications, ScopeWriteApplications), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+readOnly)
+	req.Header.Set("Accept-Language", "id")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var problem struct {
+		Detail string `json:"detail"`
+		Code   string `json:"code"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "token tidak memiliki cakupan " + ScopeWriteApplications
+	if problem.Detail != want || problem.Code != appError.CodeMissingScope {
+		t.Errorf("expected %q with %s, got %q with %s", want, appError.CodeMissingScope, problem.Detail, problem.Code)
+	}
+}
@@ -4,8 +4,15 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	PurposeTwoFactor  = "2fa"
+	PurposeOAuthState = "oauth_state"
+)
+
 type Claims struct {
-	UserId string `json:"userId"`
-	Email  string `json:"email"`
+	UserId    string `json:"userId"`
+	Email     string `json:"email"`
+	SessionId string `json:"sid,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
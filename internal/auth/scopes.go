@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+const (
+	ScopeReadApplications  = "read:applications"
+	ScopeWriteApplications = "write:applications"
+
+	PersonalAccessTokenPrefix = "jtpat_"
+)
+
+var Scopes = []string{ScopeReadApplications, ScopeWriteApplications}
+
+// Principal is the authenticated caller. Scopes only restrict personal
+// access tokens; session tokens may do anything their user can do.
+type Principal struct {
+	UserId              string
+	Email               string
+	Scopes              []string
+	PersonalAccessToken bool
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return !p.PersonalAccessToken || slices.Contains(p.Scopes, scope)
+}
+
+// TokenAuthenticator resolves a personal access token to its owner.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, rawToken string) (*Principal, error)
+}
+
+// SessionValidator confirms that the login session behind a token has not
+// been revoked or expired.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, sessionId, userId string) error
+}
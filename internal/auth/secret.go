@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix marks a value sealed by SecretCipher and the format it is in.
+const sealedPrefix = "v1:"
+
+// SecretCipher encrypts secrets that have to be read back, such as TOTP
+// seeds, and so can't be hashed. Without a key it stores them as they are,
+// which is only allowed in development.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher takes a 32 byte AES-256 key, or none for plaintext.
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) == 0 {
+		return &SecretCipher{}, nil
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("secret key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretCipher{aead: aead}, nil
+}
+
+func (c *SecretCipher) Seal(plaintext string) (string, error) {
+	if c.aead == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open returns the plaintext of value. Values stored before a key was
+// configured are returned as they are, with legacy set so that the caller
+// can seal them.
+func (c *SecretCipher) Open(value string) (plaintext string, legacy bool, err error) {
+	encoded, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return value, c.aead != nil, nil
+	}
+
+	if c.aead == nil {
+		return "", false, errors.New("secret is sealed but no key is configured")
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return "", false, errors.New("sealed secret is too short")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+
+	opened, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(opened), false, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSecretCipher(t *testing.T) {
+	c, err := NewSecretCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("round trips a sealed secret", func(t *testing.T) {
+		sealed, err := c.Seal("JBSWY3DPEHPK3PXP")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+			t.Fatalf("expected the secret to be encrypted, got %s", sealed)
+		}
+
+		opened, legacy, err := c.Open(sealed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opened != "JBSWY3DPEHPK3PXP" || legacy {
+			t.Errorf("expected the plain secret, got %s (legacy %v)", opened, legacy)
+		}
+	})
+
+	t.Run("reads plaintext stored before the key was set", func(t *testing.T) {
+		opened, legacy, err := c.Open("JBSWY3DPEHPK3PXP")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opened != "JBSWY3DPEHPK3PXP" || !legacy {
+			t.Errorf("expected a legacy plain secret, got %s (legacy %v)", opened, legacy)
+		}
+	})
+
+	t.Run("rejects a secret sealed with another key", func(t *testing.T) {
+		other, err := NewSecretCipher(bytes.Repeat([]byte{8}, 32))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sealed, err := other.Seal("JBSWY3DPEHPK3PXP")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, _, err := c.Open(sealed); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("rejects a key of the wrong size", func(t *testing.T) {
+		if _, err := NewSecretCipher([]byte("short")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 variant, truncated to six digits.
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range cases {
+		got, err := GenerateTOTPCode(secret, TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != tc.want {
+			t.Errorf("at %d expected %s, got %s", tc.unix, tc.want, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	step := TOTPStep(now)
+
+	t.Run("accepts the previous step", func(t *testing.T) {
+		code, _ := GenerateTOTPCode(secret, step-1)
+
+		got, ok := ValidateTOTP(secret, code, now, 0)
+		if !ok || got != step-1 {
+			t.Errorf("expected step %d to be accepted, got %d (%v)", step-1, got, ok)
+		}
+	})
+
+	t.Run("rejects steps outside the window", func(t *testing.T) {
+		code, _ := GenerateTOTPCode(secret, step-3)
+
+		if _, ok := ValidateTOTP(secret, code, now, 0); ok {
+			t.Error("expected code to be rejected")
+		}
+	})
+
+	t.Run("rejects replayed steps", func(t *testing.T) {
+		code, _ := GenerateTOTPCode(secret, step)
+
+		if _, ok := ValidateTOTP(secret, code, now, step); ok {
+			t.Error("expected replayed code to be rejected")
+		}
+	})
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	if HashRecoveryCode("abcde-fghij") != HashRecoveryCode(" ABCDEFGHIJ ") {
+		t.Error("expected recovery code hash to ignore case, dashes and whitespace")
+	}
+}
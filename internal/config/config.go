@@ -1,13 +1,27 @@
 package config
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"fmt"
+	"hafiztri123/hv1-job-tracker/internal/account"
 	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"hafiztri123/hv1-job-tracker/internal/logging"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
 	"hafiztri123/hv1-job-tracker/internal/middleware"
+	"hafiztri123/hv1-job-tracker/internal/oauth"
+	"hafiztri123/hv1-job-tracker/internal/password"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"hafiztri123/hv1-job-tracker/internal/token"
 	"hafiztri123/hv1-job-tracker/internal/user"
-	"hafiztri123/hv1-job-tracker/internal/utils"
 	"log/slog"
+	"net"
+	"net/url"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"time"
 
@@ -16,46 +30,158 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewConfig() *Config {
-
-	dbUser := utils.GetEnv("DB_USER", "admin")
-	dbPass := utils.GetEnv("DB_PASSWORD", "admin")
-	dbName := utils.GetEnv("DB_NAME", "app")
-	dbPort := utils.GetEnv("DB_PORT", "5432")
-	appHost := utils.GetEnv("APP_HOST", "localhost")
-
-	pgUrl := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser,
-		dbPass,
-		appHost,
-		dbPort,
-		dbName,
-	)
+// URL is the Postgres connection string.
+func (c *DBConfig) URL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
 
-	maxConns := utils.GetEnv("DB_MAX_CONNS", "10")
-	maxConnsInt, err := strconv.Atoi(maxConns)
+	return u.String()
+}
 
-	if err != nil {
-		slog.Warn("failed to set max conns, use default value", "error", err)
-		maxConnsInt = 10
+func (c *DBConfig) Timeouts() storage.Timeouts {
+	return storage.Timeouts{
+		Read:        c.ReadTimeout,
+		Write:       c.WriteTimeout,
+		Transaction: c.TxTimeout,
+	}
+}
+
+func (c *Config) Cookies() *auth.CookieConfig {
+	return &auth.CookieConfig{
+		Enabled:  c.Auth.CookieMode,
+		Secure:   c.Auth.CookieSecure,
+		SameSite: c.Auth.CookieSameSite,
+		Domain:   c.Auth.CookieDomain,
+	}
+}
+
+func (c *Config) Keys() auth.KeyConfig {
+	return auth.KeyConfig{
+		Dir:        c.Auth.JWTKeysDir,
+		PrivateKey: c.Auth.JWTPrivateKey,
+		KeyID:      c.Auth.JWTKeyID,
+		ActiveKID:  c.Auth.JWTActiveKID,
+		Secret:     c.Auth.JWTSecret,
+	}
+}
+
+func (c *Config) Metrics() metrics.Config {
+	return metrics.Config{
+		Enabled: c.Features.MetricsEnabled,
+		Addr:    c.Features.MetricsAddr,
+		Token:   c.Features.MetricsToken,
+	}
+}
+
+func (c *Config) Logging() logging.Config {
+	var level slog.Level
+	// Validate only lets known level names through.
+	_ = level.UnmarshalText([]byte(c.Features.LogLevel))
+
+	return logging.Config{
+		Level:      level,
+		RedactKeys: append(slices.Clone(logging.DefaultRedactKeys), c.Features.LogRedactKeys...),
+	}
+}
+
+func (c *Config) MailConfig() mail.Config {
+	return mail.Config{
+		Host:     c.Mail.SMTPHost,
+		Port:     c.Mail.SMTPPort,
+		Username: c.Mail.SMTPUsername,
+		Password: c.Mail.SMTPPassword,
+		From:     c.Mail.From,
+	}
+}
+
+func (c *Config) Argon2Params() password.Argon2Params {
+	return password.Argon2Params{
+		Memory:      c.Password.Argon2Memory,
+		Iterations:  c.Password.Argon2Iterations,
+		Parallelism: c.Password.Argon2Parallelism,
+		SaltLength:  c.Password.Argon2SaltLength,
+		KeyLength:   c.Password.Argon2KeyLength,
 	}
+}
 
-	return &Config{
-		DbAddr:     pgUrl,
-		DbMaxConns: int32(maxConnsInt),
+func (c *Config) PasswordPolicy() password.PolicyConfig {
+	return password.PolicyConfig{
+		MinLength:        c.Password.MinLength,
+		MaxLength:        c.Password.MaxLength,
+		MinScore:         c.Password.MinScore,
+		BreachedListFile: c.Password.BreachedListFile,
 	}
 }
 
-func NewRouterConfig(isDev bool) fiber.Config {
+// oauthProvider is the section of the provider called name.
+func (c *Config) oauthProvider(name string) (*OAuthProviderConfig, bool) {
+	switch name {
+	case "google":
+		return &c.OAuth.Google, true
+	case "github":
+		return &c.OAuth.GitHub, true
+	case "oidc":
+		return &c.OAuth.OIDC, true
+	}
+
+	return nil, false
+}
+
+func (c *Config) IdentityProviders() oauth.Config {
+	providers := make([]oauth.ProviderConfig, 0, len(c.OAuth.Providers))
+
+	for _, name := range c.OAuth.Providers {
+		p, ok := c.oauthProvider(name)
+		if !ok {
+			continue
+		}
+
+		providers = append(providers, oauth.ProviderConfig{
+			Name:         name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			AuthURL:      p.AuthURL,
+			TokenURL:     p.TokenURL,
+			APIURL:       p.APIURL,
+		})
+	}
+
+	return oauth.Config{
+		Providers:       providers,
+		RedirectBaseURL: c.OAuth.RedirectBaseURL,
+		SuccessRedirect: c.OAuth.SuccessRedirect,
+	}
+}
+
+// TwoFactor seals TOTP secrets with the configured key. Validate only lets
+// well formed keys through.
+func (c *Config) TwoFactor() user.TwoFactorConfig {
+	key, _ := base64.StdEncoding.DecodeString(c.TOTP.EncryptionKey)
+	secrets, _ := auth.NewSecretCipher(key)
+
+	return user.TwoFactorConfig{
+		Issuer:  c.TOTP.Issuer,
+		Secrets: secrets,
+	}
+}
+
+func NewRouterConfig(cfg *Config) fiber.Config {
 	baseConfig := fiber.Config{
 		AppName:      "Job Tracker v1.0",
 		ServerHeader: "Fiber",
-		ErrorHandler: middleware.ErrorHandler(isDev),
-		BodyLimit:    10 * 10 * 1024,
+		ErrorHandler: middleware.ErrorHandler(cfg.Server.Dev),
+		BodyLimit:    cfg.Limits.BodyLimit,
 	}
 
-	if isDev {
+	if cfg.Server.Dev {
 		slog.Info("using router config", "mode", "development")
 		baseConfig.ReadTimeout = 300 * time.Second
 		baseConfig.WriteTimeout = 60 * time.Second
@@ -76,26 +202,69 @@ func NewRouterConfig(isDev bool) fiber.Config {
 
 }
 
-func NewRepositories(db *pgxpool.Pool) *Repositories {
+func NewRepositories(db *pgxpool.Pool, timeouts storage.Timeouts) *Repositories {
 	return &Repositories{
-		UserRepository:        user.NewUserRepository(db),
-		ApplicationRepository: applications.NewApplicationRepository(db),
+		UserRepository:        user.NewUserRepository(db, timeouts),
+		ApplicationRepository: applications.NewApplicationRepository(db, timeouts),
+		TokenRepository:       token.NewTokenRepository(db, timeouts),
+		IdempotencyRepository: idempotency.NewIdempotencyRepository(db, timeouts),
 	}
 }
 
-func NewService(r *Repositories) *Services {
-	return &Services{
-		UserService:        user.NewUserService(r.UserRepository),
-		ApplicationService: applications.NewApplicationService(r.ApplicationRepository),
+// NewSQLiteRepositories backs the users, applications and tokens with a
+// SQLite database, for single-user setups without Postgres.
+func NewSQLiteRepositories(db *sql.DB, timeouts storage.Timeouts) *Repositories {
+	return &Repositories{
+		UserRepository:        user.NewSQLiteUserRepository(db, timeouts),
+		ApplicationRepository: applications.NewSQLiteApplicationRepository(db, timeouts),
+		TokenRepository:       token.NewSQLiteTokenRepository(db, timeouts),
+		IdempotencyRepository: idempotency.NewSQLiteIdempotencyRepository(db, timeouts),
 	}
 }
 
+func NewService(cfg *Config, r *Repositories, m *metrics.Metrics) (*Services, error) {
+	policy, err := password.NewPolicy(cfg.PasswordPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	oauthProviders, err := oauth.NewRegistryFromConfig(cfg.IdentityProviders())
+	if err != nil {
+		return nil, err
+	}
+
+	userService := user.NewUserService(
+		r.UserRepository,
+		mail.NewMailer(cfg.MailConfig()),
+		password.NewArgon2idPasswords(cfg.Argon2Params()),
+		policy,
+		cfg.TwoFactor(),
+		cfg.Mail.ConfirmURL,
+		cfg.Auth.AccountDeletionGracePeriod,
+	)
+	applicationService := applications.NewApplicationService(r.ApplicationRepository)
+	tokenService := token.NewTokenService(r.TokenRepository)
+
+	m.RegisterApplications(applicationService)
+
+	return &Services{
+		UserService:        userService,
+		ApplicationService: applicationService,
+		TokenService:       tokenService,
+		OAuthProviders:     oauthProviders,
+		AuthCookies:        cfg.Cookies(),
+		AccountService:     account.NewAccountService(userService, applicationService, tokenService, cfg.Auth.AccountDeletionGracePeriod),
+		Metrics:            m,
+		Idempotency:        idempotency.NewMiddleware(r.IdempotencyRepository, cfg.Limits.IdempotencyTTL, cfg.Limits.RequestTimeout),
+	}, nil
+}
+
 func NewRecoverConfig(isDev bool) recover.Config {
 	if isDev {
 		return recover.Config{
 			EnableStackTrace: true,
 			StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
-				slog.Error("Panic occured",
+				slog.ErrorContext(c.UserContext(), "Panic occured",
 					"error", e,
 					"path", c.Path(),
 					"method", c.Method())
@@ -108,7 +277,7 @@ func NewRecoverConfig(isDev bool) recover.Config {
 	return recover.Config{
 		EnableStackTrace: false,
 		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
-			slog.Error("panic occurred", "error", e, "path", c.Path(), "method", c.Path(), "ip", c.IP())
+			slog.ErrorContext(c.UserContext(), "panic occurred", "error", e, "path", c.Path(), "method", c.Path(), "ip", c.IP())
 		},
 	}
 
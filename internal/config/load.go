@@ -0,0 +1,409 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is one setting of Config, found through its struct tags.
+type field struct {
+	path   string // yaml path, e.g. db.max_conns
+	env    string
+	flag   string
+	def    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// Load builds the Config from defaults, the -config file, env and args, in that order.
+func Load(args []string) (*Config, error) {
+	cfg, _, err := LoadArgs(args)
+	return cfg, err
+}
+
+// LoadArgs is Load that also returns the arguments left after the flags.
+func LoadArgs(args []string) (*Config, []string, error) {
+	cfg := &Config{}
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	var errs []error
+
+	for _, f := range fields {
+		if f.def != "" {
+			errs = append(errs, f.set(f.def, "default"))
+		}
+	}
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (env CONFIG_FILE)")
+	values := make(map[string]*flagValue, len(fields))
+	for _, f := range fields {
+		values[f.flag] = &flagValue{isBool: f.value.Kind() == reflect.Bool}
+		flags.Var(values[f.flag], f.flag, f.usage)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		byPath := make(map[string]field, len(fields))
+		for _, f := range fields {
+			byPath[f.path] = f
+		}
+
+		for path, raw := range fileValues {
+			f, ok := byPath[path]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown key %s", *configFile, path))
+				continue
+			}
+
+			errs = append(errs, f.set(raw, *configFile))
+		}
+	}
+
+	for _, f := range fields {
+		if raw, ok := os.LookupEnv(f.env); ok && f.env != "" {
+			errs = append(errs, f.set(raw, f.env))
+		}
+	}
+
+	// The database host used to come from APP_HOST.
+	if _, ok := os.LookupEnv("DB_HOST"); !ok {
+		if _, ok := os.LookupEnv("APP_HOST"); ok {
+			slog.Warn("APP_HOST no longer sets the database host, use DB_HOST")
+		}
+	}
+
+	flags.Visit(func(fl *flag.Flag) {
+		if v, ok := values[fl.Name]; ok {
+			errs = append(errs, fieldByFlag(fields, fl.Name).set(v.value, "-"+fl.Name))
+		}
+	})
+
+	// Report every problem at once, not just the first.
+	if err := errors.Join(append(errs, cfg.Validate())...); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(c); err != nil {
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return err
+		}
+
+		paths := make(map[string]string)
+		for _, f := range collectFields(reflect.ValueOf(c).Elem(), "") {
+			paths[f.path] = f.env
+		}
+
+		for _, fe := range validationErrs {
+			path := yamlPath(fe.StructNamespace())
+			errs = append(errs, fmt.Errorf("%s: %s", describe(path, paths[path]), validationMessage(fe)))
+		}
+	}
+
+	if c.Auth.CookieSameSite == "none" && !c.Auth.CookieSecure {
+		errs = append(errs, errors.New("auth.cookie_same_site (AUTH_COOKIE_SAMESITE): none requires auth.cookie_secure (AUTH_COOKIE_SECURE)"))
+	}
+
+	// Browsers refuse credentialed CORS with a wildcard origin.
+	if c.Auth.CookieMode && strings.Contains(c.CORS.Origins, "*") {
+		errs = append(errs, errors.New("cors.origins (CORS_ORIGIN): auth.cookie_mode needs the allowed origins listed instead of \"*\""))
+	}
+
+	if c.Password.MaxLength < c.Password.MinLength {
+		errs = append(errs, errors.New("password.max_length (PASSWORD_MAX_LENGTH): must be at least password.min_length (PASSWORD_MIN_LENGTH)"))
+	}
+
+	for _, name := range c.OAuth.Providers {
+		provider, ok := c.oauthProvider(name)
+		if !ok {
+			continue
+		}
+
+		if provider.ClientID == "" {
+			errs = append(errs, fmt.Errorf("oauth.%s.client_id (OAUTH_%s_CLIENT_ID): is required when %s is in oauth.providers", name, strings.ToUpper(name), name))
+		}
+
+		if name == "oidc" && provider.Issuer == "" {
+			errs = append(errs, errors.New("oauth.oidc.issuer (OAUTH_OIDC_ISSUER): is required when oidc is in oauth.providers"))
+		}
+	}
+
+	if c.TOTP.EncryptionKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.TOTP.EncryptionKey); err != nil || len(key) != 32 {
+			errs = append(errs, errors.New("totp.encryption_key (TOTP_ENCRYPTION_KEY): must be 32 bytes encoded as base64"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dump renders the configuration as YAML with secrets masked.
+func (c *Config) Dump() (string, error) {
+	dump := *c
+
+	for _, f := range collectFields(reflect.ValueOf(&dump).Elem(), "") {
+		if f.secret && !f.value.IsZero() {
+			f.value.SetString(redacted)
+		}
+	}
+
+	out, err := yaml.Marshal(&dump)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+
+		path := sf.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			nested := collectFields(v.Field(i), path)
+
+			// The env tag of a struct prefixes the variables of its fields.
+			if envPrefix := sf.Tag.Get("env"); envPrefix != "" {
+				for j := range nested {
+					if nested[j].env != "" {
+						nested[j].env = envPrefix + nested[j].env
+					}
+				}
+			}
+
+			fields = append(fields, nested...)
+			continue
+		}
+
+		flagName := sf.Tag.Get("flag")
+		if flagName == "" {
+			flagName = strings.ReplaceAll(path, "_", "-")
+		}
+
+		fields = append(fields, field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			flag:   flagName,
+			def:    sf.Tag.Get("default"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func fieldByFlag(fields []field, name string) field {
+	for _, f := range fields {
+		if f.flag == name {
+			return f
+		}
+	}
+
+	panic("config: no field for flag " + name)
+}
+
+func (f field) set(raw, source string) error {
+	fail := func(expected string) error {
+		return fmt.Errorf("%s: %q is not a valid %s", describe(f.path, source), raw, expected)
+	}
+
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fail("duration")
+		}
+		f.value.SetInt(int64(d))
+
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(raw)
+
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fail("boolean")
+		}
+		f.value.SetBool(b)
+
+	case f.value.CanInt():
+		n, err := strconv.ParseInt(raw, 10, f.value.Type().Bits())
+		if err != nil {
+			return fail("integer")
+		}
+		f.value.SetInt(n)
+
+	case f.value.CanUint():
+		n, err := strconv.ParseUint(raw, 10, f.value.Type().Bits())
+		if err != nil {
+			return fail(fmt.Sprintf("integer between 0 and %d", uint64(1)<<f.value.Type().Bits()-1))
+		}
+		f.value.SetUint(n)
+
+	case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+
+	default:
+		panic("config: unsupported field type " + f.value.Type().String())
+	}
+
+	return nil
+}
+
+func describe(path, source string) string {
+	if source == "" || source == "default" {
+		return path
+	}
+
+	return fmt.Sprintf("%s (%s)", path, source)
+}
+
+// readFile flattens a YAML or TOML file to yaml paths and raw values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]any
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file type %q, expected .yaml, .yml or .toml", path, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten(tree, "", values)
+
+	return values, nil
+}
+
+func flatten(tree map[string]any, prefix string, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(value, key, values)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// yamlPath turns Config.DB.MaxConns into db.max_conns.
+func yamlPath(namespace string) string {
+	t := reflect.TypeOf(Config{})
+	var parts []string
+
+	for _, name := range strings.Split(namespace, ".")[1:] {
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			return namespace
+		}
+
+		parts = append(parts, sf.Tag.Get("yaml"))
+		t = sf.Type
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "url":
+		return "must be a URL"
+	}
+
+	return "is invalid"
+}
+
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string { return v.value }
+
+func (v *flagValue) Set(raw string) error {
+	v.value = raw
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
@@ -1,21 +1,172 @@
 package config
 
 import (
+	"hafiztri123/hv1-job-tracker/internal/account"
 	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/health"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
+	"hafiztri123/hv1-job-tracker/internal/oauth"
+	"hafiztri123/hv1-job-tracker/internal/token"
 	"hafiztri123/hv1-job-tracker/internal/user"
+	"time"
 )
 
+// Supported values of DB_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Config is every setting the server takes. Each field is read, from
+// lowest to highest precedence, from its default tag, the config file under
+// its yaml path, the environment variable in its env tag and the command
+// line flag named after its yaml path. The env tag of a nested struct
+// prefixes the variables of its fields. Fields tagged secret are redacted
+// from Dump.
 type Config struct {
-	DbAddr     string
-	DbMaxConns int32
+	Server   ServerConfig   `yaml:"server"`
+	DB       DBConfig       `yaml:"db"`
+	Auth     AuthConfig     `yaml:"auth"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Limits   LimitsConfig   `yaml:"limits"`
+	Features FeaturesConfig `yaml:"features"`
+	Mail     MailConfig     `yaml:"mail"`
+	Password PasswordConfig `yaml:"password"`
+	OAuth    OAuthConfig    `yaml:"oauth"`
+	TOTP     TOTPConfig     `yaml:"totp"`
+}
+
+type ServerConfig struct {
+	Port               int           `yaml:"port" env:"APP_PORT" default:"3000" validate:"min=1,max=65535" usage:"port the API listens on"`
+	Dev                bool          `yaml:"dev" env:"IS_DEV" default:"false" usage:"development mode, with verbose errors"`
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"5s" validate:"gte=0" usage:"how long to fail readiness before stopping, for load balancers"`
+}
+
+type DBConfig struct {
+	// Driver is DriverPostgres or DriverSQLite.
+	Driver       string        `yaml:"driver" env:"DB_DRIVER" default:"postgres" validate:"oneof=postgres sqlite" usage:"postgres, or sqlite to keep everything in one file"`
+	Host         string        `yaml:"host" env:"DB_HOST" default:"localhost" validate:"required_if=Driver postgres" usage:"postgres host"`
+	Port         int           `yaml:"port" env:"DB_PORT" default:"5432" validate:"min=1,max=65535" usage:"postgres port"`
+	User         string        `yaml:"user" env:"DB_USER" default:"admin" usage:"postgres user"`
+	Password     string        `yaml:"password" env:"DB_PASSWORD" default:"admin" secret:"true" usage:"postgres password"`
+	Name         string        `yaml:"name" env:"DB_NAME" default:"app" validate:"required_if=Driver postgres" usage:"postgres database"`
+	SSLMode      string        `yaml:"ssl_mode" env:"DB_SSLMODE" default:"disable" validate:"oneof=disable allow prefer require verify-ca verify-full" usage:"postgres sslmode"`
+	SQLitePath   string        `yaml:"sqlite_path" env:"DB_SQLITE_PATH" default:"job_tracker.db" validate:"required_if=Driver sqlite" usage:"database file when the driver is sqlite"`
+	MaxConns     int32         `yaml:"max_conns" env:"DB_MAX_CONNS" default:"10" validate:"min=1" usage:"size of the postgres pool"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"DB_READ_TIMEOUT" default:"10s" validate:"gt=0" usage:"timeout of a single read"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"DB_WRITE_TIMEOUT" default:"10s" validate:"gt=0" usage:"timeout of a single write"`
+	TxTimeout    time.Duration `yaml:"tx_timeout" env:"DB_TX_TIMEOUT" default:"30s" validate:"gt=0" usage:"timeout of a transaction"`
+	AutoMigrate  bool          `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE" flag:"auto-migrate" default:"false" usage:"apply pending migrations before serving"`
+}
+
+type AuthConfig struct {
+	JWTSecret     string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true" usage:"HS256 signing secret, used without asymmetric keys"`
+	JWTKeysDir    string `yaml:"jwt_keys_dir" env:"JWT_KEYS_DIR" usage:"directory of <kid>.pem signing keys"`
+	JWTPrivateKey string `yaml:"jwt_private_key" env:"JWT_PRIVATE_KEY" secret:"true" usage:"PEM signing key"`
+	JWTKeyID      string `yaml:"jwt_key_id" env:"JWT_KEY_ID" default:"env" usage:"kid of jwt_private_key"`
+	JWTActiveKID  string `yaml:"jwt_active_kid" env:"JWT_ACTIVE_KID" usage:"kid of the key new tokens are signed with"`
+
+	CookieMode     bool   `yaml:"cookie_mode" env:"AUTH_COOKIE_MODE" default:"false" usage:"hand sessions out as HttpOnly cookies"`
+	CookieSecure   bool   `yaml:"cookie_secure" env:"AUTH_COOKIE_SECURE" default:"true" usage:"mark the auth cookies Secure"`
+	CookieSameSite string `yaml:"cookie_same_site" env:"AUTH_COOKIE_SAMESITE" default:"lax" validate:"oneof=lax strict none" usage:"lax, strict or none"`
+	CookieDomain   string `yaml:"cookie_domain" env:"AUTH_COOKIE_DOMAIN" usage:"domain of the auth cookies"`
+
+	AccountDeletionGracePeriod time.Duration `yaml:"account_deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD" default:"720h" validate:"gte=0" usage:"how long a deleted account can be restored by logging in again"`
+}
+
+type CORSConfig struct {
+	// Origins is a comma separated list, as Fiber's CORS middleware takes it.
+	Origins string `yaml:"origins" env:"CORS_ORIGIN" default:"*" validate:"required" usage:"comma separated allowed origins"`
+}
+
+type LimitsConfig struct {
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT" default:"30s" validate:"gt=0" usage:"overall budget of one request"`
+	BodyLimit      int           `yaml:"body_limit" env:"BODY_LIMIT" default:"102400" validate:"min=1" usage:"largest accepted request body in bytes"`
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env:"IDEMPOTENCY_TTL" default:"24h" validate:"gt=0" usage:"how long Idempotency-Key responses are replayed"`
+}
+
+type FeaturesConfig struct {
+	MetricsEnabled bool   `yaml:"metrics_enabled" env:"METRICS_ENABLED" default:"true" usage:"expose /metrics"`
+	MetricsAddr    string `yaml:"metrics_addr" env:"METRICS_ADDR" usage:"serve /metrics on this address instead of the API port"`
+	MetricsToken   string `yaml:"metrics_token" env:"METRICS_TOKEN" secret:"true" usage:"bearer token scrapers have to send"`
+
+	TracesExporter string `yaml:"traces_exporter" env:"OTEL_TRACES_EXPORTER" default:"none" validate:"oneof=none otlp console" usage:"none, otlp or console"`
+
+	LogLevel      string   `yaml:"log_level" env:"LOG_LEVEL" default:"info" validate:"oneof=debug info warn error" usage:"debug, info, warn or error"`
+	LogRedactKeys []string `yaml:"log_redact_keys" env:"LOG_REDACT_KEYS" usage:"comma separated log keys to redact on top of the defaults"`
+
+	RedisURL string `yaml:"redis_url" env:"REDIS_URL" secret:"true" usage:"redis checked by /readyz when set"`
+}
+
+type MailConfig struct {
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST" usage:"SMTP server, required outside dev, emails are dropped without one"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"587" validate:"min=1,max=65535" usage:"SMTP port"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME" usage:"SMTP user, empty to send without authentication"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true" usage:"SMTP password"`
+	From         string `yaml:"from" env:"SMTP_FROM" default:"no-reply@localhost" validate:"required" usage:"sender address"`
+	ConfirmURL   string `yaml:"confirm_url" env:"EMAIL_CONFIRM_URL" default:"http://localhost:5173/confirm-email" validate:"url" usage:"page email change links point to"`
+}
+
+type PasswordConfig struct {
+	Argon2Memory      uint32 `yaml:"argon2_memory" env:"PASSWORD_ARGON2_MEMORY" default:"65536" validate:"min=1" usage:"argon2id memory in KiB"`
+	Argon2Iterations  uint32 `yaml:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS" default:"3" validate:"min=1" usage:"argon2id passes over the memory"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM" default:"2" validate:"min=1" usage:"argon2id lanes, at most 255"`
+	Argon2SaltLength  uint32 `yaml:"argon2_salt_length" env:"PASSWORD_ARGON2_SALT_LENGTH" default:"16" validate:"min=8" usage:"salt size in bytes"`
+	Argon2KeyLength   uint32 `yaml:"argon2_key_length" env:"PASSWORD_ARGON2_KEY_LENGTH" default:"32" validate:"min=16" usage:"hash size in bytes"`
+
+	MinLength        int    `yaml:"min_length" env:"PASSWORD_MIN_LENGTH" default:"8" validate:"min=1" usage:"shortest accepted password"`
+	MaxLength        int    `yaml:"max_length" env:"PASSWORD_MAX_LENGTH" default:"128" validate:"min=1" usage:"longest accepted password"`
+	MinScore         int    `yaml:"min_score" env:"PASSWORD_MIN_SCORE" default:"3" validate:"min=0,max=4" usage:"weakest accepted strength score, 0 to 4"`
+	BreachedListFile string `yaml:"breached_list_file" env:"PASSWORD_BREACHED_LIST_FILE" usage:"file of common or breached passwords to refuse, one per line"`
+}
+
+type OAuthConfig struct {
+	// Providers are the names of the enabled sections below.
+	Providers       []string `yaml:"providers" env:"OAUTH_PROVIDERS" validate:"dive,oneof=google github oidc" usage:"comma separated identity providers: google, github or oidc"`
+	RedirectBaseURL string   `yaml:"redirect_base_url" env:"OAUTH_REDIRECT_BASE_URL" default:"http://localhost:3000/api/v1/auth/oauth" validate:"url" usage:"URL the provider callbacks live under"`
+	SuccessRedirect string   `yaml:"success_redirect" env:"OAUTH_SUCCESS_REDIRECT" default:"http://localhost:5173/auth/callback" validate:"url" usage:"SPA page that finishes a login"`
+
+	Google OAuthProviderConfig `yaml:"google" env:"OAUTH_GOOGLE_"`
+	GitHub OAuthProviderConfig `yaml:"github" env:"OAUTH_GITHUB_"`
+	OIDC   OAuthProviderConfig `yaml:"oidc" env:"OAUTH_OIDC_"`
+}
+
+// OAuthProviderConfig only needs the client credentials for google and
+// github; the rest overrides their presets.
+type OAuthProviderConfig struct {
+	ClientID     string   `yaml:"client_id" env:"CLIENT_ID" usage:"client id, required when the provider is enabled"`
+	ClientSecret string   `yaml:"client_secret" env:"CLIENT_SECRET" secret:"true" usage:"client secret"`
+	Issuer       string   `yaml:"issuer" env:"ISSUER" usage:"OIDC issuer, required for oidc"`
+	Scopes       []string `yaml:"scopes" env:"SCOPES" usage:"comma separated scopes"`
+	RedirectURL  string   `yaml:"redirect_url" env:"REDIRECT_URL" usage:"callback URL, by default under redirect_base_url"`
+	AuthURL      string   `yaml:"auth_url" env:"AUTH_URL" usage:"authorization endpoint of github"`
+	TokenURL     string   `yaml:"token_url" env:"TOKEN_URL" usage:"token endpoint of github"`
+	APIURL       string   `yaml:"api_url" env:"API_URL" usage:"API of github, for GitHub Enterprise"`
+}
+
+type TOTPConfig struct {
+	Issuer string `yaml:"issuer" env:"TOTP_ISSUER" default:"Job Tracker" validate:"required" usage:"issuer shown in authenticator apps"`
+	// EncryptionKey is base64 and decodes to 32 bytes, which Validate checks.
+	EncryptionKey string `yaml:"encryption_key" env:"TOTP_ENCRYPTION_KEY" secret:"true" usage:"base64 AES-256 key sealing TOTP secrets at rest, required outside dev mode"`
 }
 
 type Services struct {
 	UserService        *user.UserService
 	ApplicationService *applications.ApplicationService
+	TokenService       *token.TokenService
+	OAuthProviders     *oauth.Registry
+	AuthCookies        *auth.CookieConfig
+	AccountService     *account.AccountService
+	Metrics            *metrics.Metrics
+	Health             *health.Checker
+	Idempotency        *idempotency.Middleware
 }
 
 type Repositories struct {
-	UserRepository        *user.UserRepository
-	ApplicationRepository *applications.ApplicationRepository
+	UserRepository        user.Repository
+	ApplicationRepository applications.Repository
+	TokenRepository       token.Repository
+	IdempotencyRepository idempotency.Repository
 }
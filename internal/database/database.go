@@ -2,23 +2,45 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"hafiztri123/hv1-job-tracker/internal/config"
+	"hafiztri123/hv1-job-tracker/internal/tracing"
+	"hafiztri123/hv1-job-tracker/migrations"
+	"net/url"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	_ "modernc.org/sqlite"
 )
 
+// Database holds the Postgres pool or, with DB_DRIVER=sqlite, the SQLite
+// handle. Exactly one of them is set.
 type Database struct {
-	Pool *pgxpool.Pool
+	Pool   *pgxpool.Pool
+	SQLite *sql.DB
 }
 
 func NewDatabase(cfg *config.Config, ctx context.Context) (*Database, error) {
+	switch cfg.DB.Driver {
+	case config.DriverPostgres:
+	case config.DriverSQLite:
+		db, err := OpenSQLite(ctx, cfg.DB.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
 
-	config, err := pgxpool.ParseConfig(cfg.DbAddr)
+		return &Database{SQLite: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q, expected %q or %q", cfg.DB.Driver, config.DriverPostgres, config.DriverSQLite)
+	}
+
+	config, err := pgxpool.ParseConfig(cfg.DB.URL())
 	if err != nil {
 		return nil, err
 	}
 
-	config.MaxConns = cfg.DbMaxConns
+	config.MaxConns = cfg.DB.MaxConns
+	config.ConnConfig.Tracer = tracing.NewQueryTracer()
 
 	dbPool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -29,8 +51,53 @@ func NewDatabase(cfg *config.Config, ctx context.Context) (*Database, error) {
 	return &Database{Pool: dbPool}, nil
 }
 
+// OpenSQLite opens or creates the database file at path. Foreign keys are
+// off by default in SQLite and have to be enabled per connection, and a
+// single connection avoids SQLITE_BUSY between concurrent writers.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma": {"foreign_keys(1)", "journal_mode(WAL)", "busy_timeout(5000)"},
+	}.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrator returns a Migrator with the migration set of the driver in use.
+func (d *Database) Migrator() (*Migrator, error) {
+	if d.SQLite != nil {
+		return NewSQLiteMigrator(d.SQLite, migrations.SQLiteFS)
+	}
+
+	return NewMigrator(d.Pool, migrations.FS)
+}
+
+// Ping checks that the database in use can be reached.
+func (d *Database) Ping(ctx context.Context) error {
+	if d.SQLite != nil {
+		return d.SQLite.PingContext(ctx)
+	}
+
+	return d.Pool.Ping(ctx)
+}
+
 func (d *Database) Close() {
 	if d.Pool != nil {
 		d.Pool.Close()
 	}
+
+	if d.SQLite != nil {
+		d.SQLite.Close()
+	}
 }
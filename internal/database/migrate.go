@@ -0,0 +1,366 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NilVersion is the version of a database without any migration applied.
+const NilVersion = -1
+
+// migrationLockKey is shared by every replica so only one of them migrates
+// at a time. The others wait for the lock and then find nothing to do.
+const migrationLockKey = 7_420_145_210_368
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+var ErrDirty = errors.New("database is dirty, fix the failed migration and run migrate force")
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Migrator applies the embedded migrations. It keeps its state in the same
+// schema_migrations table as golang-migrate, so databases migrated with the
+// migrate CLI carry on where they left off.
+type Migrator struct {
+	store      migrationStore
+	migrations []Migration
+}
+
+// migrationStore is what Migrator needs from a database driver.
+type migrationStore interface {
+	// lock keeps other migrators out until unlock is called.
+	lock(ctx context.Context) (unlock func(), err error)
+	ensureTable(ctx context.Context) error
+	version(ctx context.Context) (int64, bool, error)
+	setVersion(ctx context.Context, version int64, dirty bool) error
+	exec(ctx context.Context, sql string) error
+}
+
+func NewMigrator(pool *pgxpool.Pool, files fs.FS) (*Migrator, error) {
+	return newMigrator(&postgresMigrationStore{pool: pool}, files)
+}
+
+func newMigrator(store migrationStore, files fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if match[3] == "up" {
+			migration.up = string(body)
+		} else {
+			migration.down = string(body)
+		}
+	}
+
+	migrator := &Migrator{store: store}
+	for _, migration := range byVersion {
+		if migration.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+
+		migrator.migrations = append(migrator.migrations, *migration)
+	}
+
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+
+	return migrator, nil
+}
+
+// Up applies every pending migration and returns how many ran.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	err := m.withLock(ctx, func() error {
+		version, dirty, err := m.store.version(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err := m.run(ctx, migration.up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	err := m.withLock(ctx, func() error {
+		version, dirty, err := m.store.version(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+
+			if migration.down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			previous := int64(NilVersion)
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := m.run(ctx, migration.down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force sets the version without running anything and clears the dirty
+// flag, after a failed migration has been cleaned up by hand.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func() error {
+		return m.store.setVersion(ctx, version, false)
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (int64, bool, []MigrationStatus, error) {
+	if err := m.store.ensureTable(ctx); err != nil {
+		return 0, false, nil, err
+	}
+
+	version, dirty, err := m.store.version(ctx)
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version,
+		})
+	}
+
+	return version, dirty, statuses, nil
+}
+
+// Latest is the version the embedded migrations bring the database to.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return NilVersion
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CheckCurrent returns an error unless every embedded migration has been
+// applied. A newer version passes, so replicas still running the previous
+// release stay up while a rolling deploy migrates ahead of them. Unlike
+// Status it never creates the migrations table, so probes can call it.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, dirty, err := m.store.version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return ErrDirty
+	}
+
+	if latest := m.Latest(); version < latest {
+		return fmt.Errorf("database is at version %d, expected %d", version, latest)
+	}
+
+	return nil
+}
+
+// run follows golang-migrate: the target version is recorded as dirty first
+// so that a half-applied migration is noticed on the next run.
+func (m *Migrator) run(ctx context.Context, sql string, version int64) error {
+	if err := m.store.setVersion(ctx, version, true); err != nil {
+		return err
+	}
+
+	if err := m.store.exec(ctx, sql); err != nil {
+		return err
+	}
+
+	return m.store.setVersion(ctx, version, false)
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	unlock, err := m.store.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.store.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+// postgresMigrationStore holds on to one connection while locked, because
+// advisory locks belong to the session that took them.
+type postgresMigrationStore struct {
+	pool *pgxpool.Pool
+	conn *pgxpool.Conn
+}
+
+type pgQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+func (s *postgresMigrationStore) querier() pgQuerier {
+	if s.conn != nil {
+		return s.conn
+	}
+
+	return s.pool
+}
+
+func (s *postgresMigrationStore) lock(ctx context.Context) (func(), error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, `select pg_advisory_lock($1)`, int64(migrationLockKey)); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+
+	s.conn = conn
+
+	return func() {
+		_, _ = conn.Exec(context.Background(), `select pg_advisory_unlock($1)`, int64(migrationLockKey))
+		s.conn = nil
+		conn.Release()
+	}, nil
+}
+
+func (s *postgresMigrationStore) ensureTable(ctx context.Context) error {
+	_, err := s.querier().Exec(ctx, `create table if not exists schema_migrations (version bigint not null primary key, dirty boolean not null)`)
+	return err
+}
+
+func (s *postgresMigrationStore) exec(ctx context.Context, sql string) error {
+	_, err := s.querier().Exec(ctx, sql)
+	return err
+}
+
+func (s *postgresMigrationStore) version(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := s.querier().QueryRow(ctx, `select version, dirty from schema_migrations limit 1`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return NilVersion, false, nil
+		}
+
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func (s *postgresMigrationStore) setVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := s.querier().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `truncate schema_migrations`); err != nil {
+		return err
+	}
+
+	if version != NilVersion || dirty {
+		if _, err := tx.Exec(ctx, `insert into schema_migrations (version, dirty) values ($1, $2)`, version, dirty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
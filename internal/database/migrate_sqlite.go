@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+)
+
+func NewSQLiteMigrator(db *sql.DB, files fs.FS) (*Migrator, error) {
+	return newMigrator(&sqliteMigrationStore{db: db}, files)
+}
+
+// sqliteMigrationStore has no lock: a SQLite database belongs to a single
+// server process, and OpenSQLite limits it to one connection anyway.
+type sqliteMigrationStore struct {
+	db *sql.DB
+}
+
+func (s *sqliteMigrationStore) lock(context.Context) (func(), error) {
+	return func() {}, nil
+}
+
+func (s *sqliteMigrationStore) ensureTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `create table if not exists schema_migrations (version integer not null primary key, dirty boolean not null)`)
+	return err
+}
+
+func (s *sqliteMigrationStore) exec(ctx context.Context, sql string) error {
+	_, err := s.db.ExecContext(ctx, sql)
+	return err
+}
+
+func (s *sqliteMigrationStore) version(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := s.db.QueryRowContext(ctx, `select version, dirty from schema_migrations limit 1`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NilVersion, false, nil
+		}
+
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func (s *sqliteMigrationStore) setVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `delete from schema_migrations`); err != nil {
+		return err
+	}
+
+	if version != NilVersion || dirty {
+		if _, err := tx.ExecContext(ctx, `insert into schema_migrations (version, dirty) values ($1, $2)`, version, dirty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
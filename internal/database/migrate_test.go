@@ -0,0 +1,39 @@
+package database
+
+import (
+	"hafiztri123/hv1-job-tracker/migrations"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewMigratorLoadsEmbeddedMigrations(t *testing.T) {
+	migrator, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(migrator.migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	for i, migration := range migrator.migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("expected version %d, got %d (%s)", i+1, migration.Version, migration.Name)
+		}
+
+		if migration.down == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestNewMigratorRejectsMissingUp(t *testing.T) {
+	files := fstest.MapFS{
+		"000001_init.up.sql":   {Data: []byte("create table a (id int);")},
+		"000002_next.down.sql": {Data: []byte("drop table b;")},
+	}
+
+	if _, err := NewMigrator(nil, files); err == nil {
+		t.Fatal("expected an error for a migration without an up file")
+	}
+}
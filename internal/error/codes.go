@@ -0,0 +1,90 @@
+package appError
+
+import "net/http"
+
+// Codes are the stable, machine-readable half of an error. Clients switch
+// on them, so once released a code keeps its meaning; add a new one rather
+// than repurposing it. web/src/services/type/problem.type.ts mirrors this
+// list.
+const (
+	CodeInternal           = "internal"
+	CodeServiceUnavailable = "service.unavailable"
+	CodeUpstreamFailed     = "upstream.failed"
+
+	CodeBadRequest       = "request.invalid"
+	CodeValidationFailed = "request.validation_failed"
+	CodeTooLarge         = "request.too_large"
+	CodeTimeout          = "request.timeout"
+	CodeRouteNotFound    = "route.not_found"
+	CodeMethodNotAllowed = "route.method_not_allowed"
+
+	CodeNotFound             = "resource.not_found"
+	CodeConflict             = "resource.conflict"
+	CodePreconditionFailed   = "resource.precondition_failed"
+	CodePreconditionRequired = "resource.precondition_required"
+
+	CodeIdempotencyKeyInvalid = "idempotency.key_invalid"
+	CodeIdempotencyKeyReused  = "idempotency.key_reused"
+	CodeIdempotencyInProgress = "idempotency.in_progress"
+
+	CodeUnauthorized         = "auth.unauthorized"
+	CodeForbidden            = "auth.forbidden"
+	CodeInvalidCredentials   = "auth.invalid_credentials"
+	CodeInvalidToken         = "auth.invalid_token"
+	CodeInvalidCSRF          = "auth.invalid_csrf"
+	CodeMissingScope         = "auth.missing_scope"
+	CodeInvalidChallenge     = "auth.invalid_challenge"
+	CodeCookieModeDisabled   = "auth.cookie_mode_disabled"
+	CodeInvalidTwoFactorCode = "two_factor.invalid_code"
+	CodeTwoFactorEnabled     = "two_factor.already_enabled"
+	CodeTwoFactorNotEnabled  = "two_factor.not_enabled"
+	CodeTwoFactorNotStarted  = "two_factor.setup_not_started"
+	CodeTwoFactorLocked      = "two_factor.too_many_attempts"
+
+	CodeEmailTaken              = "user.email_taken"
+	CodeEmailConfirmationFailed = "user.email_confirmation_invalid"
+	CodeEmailChangeNotConfirmed = "user.email_change_not_confirmed"
+	CodeDeletionNotConfirmed    = "account.deletion_not_confirmed"
+
+	CodeIdentityLinked           = "identity.already_linked"
+	CodeIdentityEmailNotVerified = "identity.email_not_verified"
+	CodeProviderNotFound         = "identity_provider.not_found"
+	CodeProviderUnavailable      = "identity_provider.unavailable"
+
+	CodeApplicationNotFound        = "application.not_found"
+	CodeApplicationVersionMismatch = "application.version_mismatch"
+	CodeTokenNotFound              = "token.not_found"
+	CodeSessionNotFound            = "session.not_found"
+)
+
+// CodeForStatus is the code of an error that doesn't carry one.
+func CodeForStatus(status int) string {
+	switch {
+	case status == http.StatusBadRequest:
+		return CodeBadRequest
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case status == http.StatusPreconditionRequired:
+		return CodePreconditionRequired
+	case status == http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case status == http.StatusBadGateway:
+		return CodeUpstreamFailed
+	case status == http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case status >= http.StatusInternalServerError:
+		return CodeInternal
+	}
+
+	return CodeBadRequest
+}
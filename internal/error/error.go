@@ -10,31 +10,56 @@ var (
 		Err:        errors.New("email already exists"),
 		Message:    "Email already exists",
 		StatusCode: http.StatusConflict,
+		Code:       CodeEmailTaken,
 	}
 
 	ErrInvalidInput = &AppError{
 		Err:        errors.New("invalid input"),
 		Message:    "Invalid input provided",
 		StatusCode: http.StatusBadRequest,
+		Code:       CodeBadRequest,
 	}
 
 	ErrNotFound = &AppError{
 		Err:        errors.New("not found"),
 		Message:    "Resource not found",
 		StatusCode: http.StatusNotFound,
+		Code:       CodeNotFound,
 	}
 
 	ErrUnauthorized = &AppError{
 		Err:        errors.New("unauthorized"),
 		Message:    "Unauthorized access",
 		StatusCode: http.StatusUnauthorized,
+		Code:       CodeUnauthorized,
+	}
+
+	ErrInvalidTwoFactorCode = &AppError{
+		Err:        errors.New("invalid two-factor code"),
+		Message:    "Invalid two-factor code",
+		StatusCode: http.StatusBadRequest,
+		Code:       CodeInvalidTwoFactorCode,
+	}
+
+	ErrInvalidChallenge = &AppError{
+		Err:        errors.New("invalid challenge token"),
+		Message:    "Invalid or expired challenge token",
+		StatusCode: http.StatusUnauthorized,
+		Code:       CodeInvalidChallenge,
+	}
+
+	ErrTwoFactorLocked = &AppError{
+		Err:        errors.New("too many failed two-factor attempts"),
+		Message:    "Too many failed two-factor attempts, try again later",
+		StatusCode: http.StatusTooManyRequests,
+		Code:       CodeTwoFactorLocked,
 	}
 )
 
 func NewNotFoundErr(errorMsg string) *AppError {
 	return &AppError{
 		Err:        errors.New(errorMsg),
-		Message:    "Not Found",
+		Message:    errorMsg,
 		StatusCode: http.StatusNotFound,
 	}
 }
@@ -47,10 +72,30 @@ func NewInternalServerError(errorMsg string) *AppError {
 	}
 }
 
+// WrapInternalServerError keeps err in the chain, so callers can still tell
+// a cancelled or timed out request apart from a real failure.
+func WrapInternalServerError(err error) *AppError {
+	return &AppError{
+		Err:        err,
+		Message:    "Internal Server Error",
+		StatusCode: http.StatusInternalServerError,
+	}
+}
+
 func NewBadRequestError(errorMsg string) *AppError {
 	return &AppError{
 		Err:        errors.New(errorMsg),
-		Message:    "Bad Request",
+		Message:    errorMsg,
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+func NewValidationError(details any) *AppError {
+	return &AppError{
+		Err:        errors.New("validation failed"),
+		Message:    "The request body is invalid",
 		StatusCode: http.StatusBadRequest,
+		Code:       CodeValidationFailed,
+		Details:    details,
 	}
 }
@@ -4,6 +4,12 @@ type AppError struct {
 	Err        error  `json:"error"`
 	Message    string `json:"message"`
 	StatusCode int    `json:"status"`
+	// Code is one of the Code constants. Without it the code follows from
+	// StatusCode, see CodeOf.
+	Code string `json:"code"`
+	// Details is sent to the client as the "errors" member, e.g. a list of
+	// field-level validation errors.
+	Details any `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -18,6 +24,22 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
+// WithCode sets the code of a freshly built error. Don't call it on the
+// shared Err* values.
+func (e *AppError) WithCode(code string) *AppError {
+	e.Code = code
+	return e
+}
+
+// CodeOf returns the code of e, falling back to the one of its status.
+func CodeOf(e *AppError) string {
+	if e.Code != "" {
+		return e.Code
+	}
+
+	return CodeForStatus(e.StatusCode)
+}
+
 func New(err error, message string, statusCode int) *AppError {
 	return &AppError{
 		Err:        err,
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bufio"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportAccountHandler streams the user's data as a ZIP download instead of
+// the usual JSON envelope.
+func (h *Handler) ExportAccountHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	export, err := h.AccountService.Export(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	filename := "job-tracker-export-" + time.Now().UTC().Format("20060102") + ".zip"
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Attachment(filename)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.WriteZip(w); err != nil {
+			slog.Error("failed to stream account export", "userId", userId, "error", err)
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			slog.Error("failed to stream account export", "userId", userId, "error", err)
+		}
+	})
+
+	return nil
+}
+
+func (h *Handler) DeleteAccountHandler(c *fiber.Ctx) error {
+	var dto user.DeleteAccountDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	deletion, err := h.AccountService.Delete(c.UserContext(), userId, &dto)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Account scheduled for deletion"),
+		utils.WithStatus(http.StatusAccepted),
+		utils.WithData(deletion),
+	)
+}
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/auth"
 	"hafiztri123/hv1-job-tracker/internal/config"
 	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/health"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
 	"hafiztri123/hv1-job-tracker/internal/user"
 	"hafiztri123/hv1-job-tracker/internal/utils"
+	"log/slog"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,6 +19,13 @@ func NewHandler(services *config.Services) *Handler {
 	return &Handler{
 		UserService:        services.UserService,
 		ApplicationService: services.ApplicationService,
+		TokenService:       services.TokenService,
+		OAuthProviders:     services.OAuthProviders,
+		AuthCookies:        services.AuthCookies,
+		AccountService:     services.AccountService,
+		Metrics:            services.Metrics,
+		Health:             services.Health,
+		Idempotency:        services.Idempotency,
 	}
 }
 
@@ -22,18 +33,41 @@ func (h *Handler) HealthHandler(c *fiber.Ctx) error {
 	return c.SendString("OK")
 }
 
+// LivezHandler only tells that the process serves requests. It must not
+// depend on the database, or an outage would get every replica restarted.
+func (h *Handler) LivezHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": health.StatusOK})
+}
+
+// ReadyzHandler tells whether this replica should get traffic, with the
+// status of every dependency check. Failures are only detailed in the log.
+func (h *Handler) ReadyzHandler(c *fiber.Ctx) error {
+	report := h.Health.Ready(c.UserContext())
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if !report.Ready() {
+		return c.Status(http.StatusServiceUnavailable).JSON(report)
+	}
+
+	return c.JSON(report)
+}
+
+// JWKSHandler publishes the public keys tokens are signed with, in the plain
+// JWK Set format clients expect rather than the usual response envelope.
+func (h *Handler) JWKSHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(auth.CurrentJWKS())
+}
+
 func (h *Handler) RegisterUserHandler(c *fiber.Ctx) error {
 	dto := new(user.RegisterUserDto)
 
 	if err := c.BodyParser(dto); err != nil {
-		return utils.NewResponse(
-			c,
-			utils.WithMessage("Bad Request"),
-			utils.WithStatus(http.StatusBadRequest),
-		)
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(dto); errors != nil {
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithStatus(http.StatusBadRequest),
@@ -42,7 +76,7 @@ func (h *Handler) RegisterUserHandler(c *fiber.Ctx) error {
 		)
 	}
 
-	err := h.UserService.RegisterUser(dto)
+	err := h.UserService.RegisterUser(c.UserContext(), dto)
 
 	if err != nil {
 		return err
@@ -59,14 +93,10 @@ func (h *Handler) LoginUserHandler(c *fiber.Ctx) error {
 	dto := new(user.LoginUserDto)
 
 	if err := c.BodyParser(dto); err != nil {
-		return utils.NewResponse(
-			c,
-			utils.WithMessage("Bad Request"),
-			utils.WithStatus(http.StatusBadRequest),
-		)
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(dto); errors != nil {
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithStatus(http.StatusBadRequest),
@@ -75,31 +105,34 @@ func (h *Handler) LoginUserHandler(c *fiber.Ctx) error {
 		)
 	}
 
-	token, err := h.UserService.LoginUser(dto)
+	result, err := h.UserService.LoginUser(c.UserContext(), dto, clientInfo(c))
 	if err != nil {
+		h.Metrics.ObserveLogin(metrics.LoginPassword, metrics.LoginResult(err))
 		return err
 	}
 
-	return utils.NewResponse(
-		c,
-		utils.WithMessage("Login success"),
-		utils.WithData(token),
-	)
+	if result.TwoFactorRequired {
+		h.Metrics.ObserveLogin(metrics.LoginPassword, metrics.LoginTwoFactorRequired)
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Two-factor authentication required"),
+			utils.WithData(result),
+		)
+	}
+
+	h.Metrics.ObserveLogin(metrics.LoginPassword, metrics.LoginSuccess)
+
+	return h.sessionResponse(c, result.Token)
 }
 
 func (h *Handler) CreateApplicationHandler(c *fiber.Ctx) error {
 	dto := new(applications.CreateApplicationDto)
 
 	if err := c.BodyParser(dto); err != nil {
-		return utils.NewResponse(
-			c,
-			utils.WithMessage("Bad Request"),
-			utils.WithStatus(http.StatusBadRequest),
-			utils.WithError(err.Error()),
-		)
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(dto); errors != nil {
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithStatus(http.StatusBadRequest),
@@ -117,7 +150,7 @@ func (h *Handler) CreateApplicationHandler(c *fiber.Ctx) error {
 		)
 	}
 
-	err := h.ApplicationService.CreateApplication(dto, userId)
+	err := h.ApplicationService.CreateApplication(c.UserContext(), dto, userId)
 	if err != nil {
 		return err
 	}
@@ -134,7 +167,7 @@ func (h *Handler) GetApplicationsHandler(c *fiber.Ctx) error {
 	var queryParams applications.ApplicationQueryParams
 
 	if err := c.QueryParser(&queryParams); err != nil {
-		return appError.NewBadRequestError(err.Error())
+		return invalidQuery(c, err)
 	}
 
 	userId, ok := c.Locals("userId").(string)
@@ -146,7 +179,7 @@ func (h *Handler) GetApplicationsHandler(c *fiber.Ctx) error {
 		)
 	}
 
-	applications, err := h.ApplicationService.GetApplications(userId, queryParams)
+	applications, err := h.ApplicationService.GetApplications(c.UserContext(), userId, queryParams)
 	if err != nil {
 		return err
 	}
@@ -158,6 +191,26 @@ func (h *Handler) GetApplicationsHandler(c *fiber.Ctx) error {
 	)
 }
 
+func (h *Handler) GetApplicationHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	application, err := h.ApplicationService.GetApplication(c.UserContext(), userId, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, applications.ETag(application.Version))
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Successfully get application"),
+		utils.WithData(application),
+	)
+}
+
 func (h *Handler) DeleteApplicationHandler(c *fiber.Ctx) error {
 
 	id := c.Params("id")
@@ -171,7 +224,12 @@ func (h *Handler) DeleteApplicationHandler(c *fiber.Ctx) error {
 		return appError.ErrInvalidInput
 	}
 
-	err := h.ApplicationService.DeleteApplications(userId, id)
+	version, err := applications.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	err = h.ApplicationService.DeleteApplications(c.UserContext(), userId, id, version)
 
 	if err != nil {
 		return err
@@ -187,7 +245,7 @@ func (h *Handler) GetApplicationOptionsHandler(c *fiber.Ctx) error {
 	var queryParams applications.ApplicationOptionQueryParams
 
 	if err := c.QueryParser(&queryParams); err != nil {
-		return appError.NewBadRequestError(err.Error())
+		return invalidQuery(c, err)
 	}
 
 	options := h.ApplicationService.GetApplicationOptions(queryParams)
@@ -204,10 +262,10 @@ func (h *Handler) UpdateApplicationHandler(c *fiber.Ctx) error {
 	var body applications.UpdateApplicationDto
 
 	if err := c.BodyParser(&body); err != nil {
-		return appError.NewBadRequestError(err.Error())
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(body); errors != nil {
+	if errors := utils.ValidateStruct(c, body); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithMessage("Bad Request"),
@@ -226,11 +284,18 @@ func (h *Handler) UpdateApplicationHandler(c *fiber.Ctx) error {
 		return appError.NewBadRequestError("Application id is missing")
 	}
 
-	err := h.ApplicationService.UpdateApplication(body, userId, applicationId)
+	version, err := applications.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.ApplicationService.UpdateApplication(c.UserContext(), body, userId, applicationId, version)
 	if err != nil {
 		return err
 	}
 
+	c.Set(fiber.HeaderETag, applications.ETag(updated))
+
 	return utils.NewResponse(
 		c,
 		utils.WithMessage("Application updated"),
@@ -238,14 +303,63 @@ func (h *Handler) UpdateApplicationHandler(c *fiber.Ctx) error {
 
 }
 
+// PatchApplicationHandler applies an RFC 7396 merge patch, which unlike PUT
+// can clear a field by setting it to null.
+func (h *Handler) PatchApplicationHandler(c *fiber.Ctx) error {
+	patch, notNull, err := applications.ParseMergePatch(c.Body())
+	if err != nil {
+		if _, ok := err.(*appError.AppError); ok {
+			return err
+		}
+		return invalidBody(c, err)
+	}
+
+	errors := append(utils.ValidateStruct(c, patch.Set), utils.NotNullErrors(c, notNull)...)
+	if len(errors) > 0 {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	applicationId := c.Params("id")
+	if applicationId == "" {
+		return appError.NewBadRequestError("Application id is missing")
+	}
+
+	version, err := applications.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.ApplicationService.PatchApplication(c.UserContext(), patch, userId, applicationId, version)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, applications.ETag(updated))
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Application updated"),
+	)
+}
+
 func (h *Handler) BatchDeleteApplicationHandler(c *fiber.Ctx) error {
 	var dto applications.BatchDeleteDto
 
 	if err := c.BodyParser(&dto); err != nil {
-		return appError.NewBadRequestError(err.Error())
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(dto); errors != nil {
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithMessage("Bad Request"),
@@ -259,7 +373,7 @@ func (h *Handler) BatchDeleteApplicationHandler(c *fiber.Ctx) error {
 		return appError.ErrUnauthorized
 	}
 
-	err := h.ApplicationService.BatchDeleteApplications(userId, &dto)
+	err := h.ApplicationService.BatchDeleteApplications(c.UserContext(), userId, &dto)
 	if err != nil {
 		return err
 	}
@@ -274,10 +388,10 @@ func (h *Handler) BatchUpdateStatusApplicationHandler(c *fiber.Ctx) error {
 	var dto applications.BatchUpdateStatusDto
 
 	if err := c.BodyParser(&dto); err != nil {
-		return appError.NewBadRequestError(err.Error())
+		return invalidBody(c, err)
 	}
 
-	if errors := utils.ValidateStruct(dto); errors != nil {
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
 		return utils.NewResponse(
 			c,
 			utils.WithMessage("Bad Request"),
@@ -291,7 +405,7 @@ func (h *Handler) BatchUpdateStatusApplicationHandler(c *fiber.Ctx) error {
 		return appError.ErrUnauthorized
 	}
 
-	err := h.ApplicationService.BatchUpdateStatusApplications(userId, &dto)
+	err := h.ApplicationService.BatchUpdateStatusApplications(c.UserContext(), userId, &dto)
 	if err != nil {
 		return err
 	}
@@ -308,7 +422,7 @@ func (h *Handler) VerifyTokenHandler(c *fiber.Ctx) error {
 		return appError.ErrUnauthorized
 	}
 
-	fullUser, err := h.UserService.Repo.FindUserById(userId)
+	fullUser, err := h.UserService.Repo.FindUserById(c.UserContext(), userId)
 
 	if err != nil {
 		return err
@@ -327,8 +441,82 @@ func (h *Handler) VerifyTokenHandler(c *fiber.Ctx) error {
 }
 
 func (h *Handler) LogoutHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	sessionId, _ := c.Locals("sessionId").(string)
+
+	if err := h.UserService.RevokeSession(c.UserContext(), userId, sessionId); err != nil {
+		return err
+	}
+
+	if h.AuthCookies.Enabled {
+		h.AuthCookies.ClearSessionCookies(c)
+	}
+
 	return utils.NewResponse(
 		c,
 		utils.WithMessage("Logged out successfully"),
 	)
 }
+
+// sessionResponse hands a freshly issued session token to the client: in the
+// body by default, or as an HttpOnly cookie in cookie mode, where only the
+// CSRF token is returned.
+func (h *Handler) sessionResponse(c *fiber.Ctx, token string) error {
+	if !h.AuthCookies.Enabled {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Login success"),
+			utils.WithData(user.LoginResult{Token: token}),
+		)
+	}
+
+	csrfToken, err := h.AuthCookies.SetSessionCookies(c, token)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Login success"),
+		utils.WithData(user.LoginResult{CSRFToken: csrfToken}),
+	)
+}
+
+func (h *Handler) CSRFTokenHandler(c *fiber.Ctx) error {
+	if !h.AuthCookies.Enabled {
+		return appError.NewNotFoundErr("Cookie auth mode is disabled").WithCode(appError.CodeCookieModeDisabled)
+	}
+
+	csrfToken, err := h.AuthCookies.CSRFToken(c)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithData(auth.CSRFTokenResponse{CSRFToken: csrfToken}),
+	)
+}
+
+func clientInfo(c *fiber.Ctx) user.ClientInfo {
+	return user.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
+// invalidBody keeps the parser's message, which can quote the body back, in
+// the log rather than the response.
+func invalidBody(c *fiber.Ctx, err error) error {
+	slog.WarnContext(c.UserContext(), "invalid request body", "path", c.Path(), "error", err)
+	return appError.New(err, "invalid request body", http.StatusBadRequest).WithCode(appError.CodeBadRequest)
+}
+
+func invalidQuery(c *fiber.Ctx, err error) error {
+	slog.WarnContext(c.UserContext(), "invalid query parameters", "path", c.Path(), "error", err)
+	return appError.New(err, "invalid query parameters", http.StatusBadRequest).WithCode(appError.CodeBadRequest)
+}
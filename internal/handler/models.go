@@ -1,11 +1,25 @@
 package handler
 
 import (
+	"hafiztri123/hv1-job-tracker/internal/account"
 	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/health"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
+	"hafiztri123/hv1-job-tracker/internal/oauth"
+	"hafiztri123/hv1-job-tracker/internal/token"
 	"hafiztri123/hv1-job-tracker/internal/user"
 )
 
 type Handler struct {
 	UserService        *user.UserService
 	ApplicationService *applications.ApplicationService
+	TokenService       *token.TokenService
+	OAuthProviders     *oauth.Registry
+	AuthCookies        *auth.CookieConfig
+	AccountService     *account.AccountService
+	Metrics            *metrics.Metrics
+	Health             *health.Checker
+	Idempotency        *idempotency.Middleware
 }
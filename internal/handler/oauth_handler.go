@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
+	"hafiztri123/hv1-job-tracker/internal/oauth"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) OAuthProvidersHandler(c *fiber.Ctx) error {
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Successfully get identity providers"),
+		utils.WithData(h.OAuthProviders.Names()),
+	)
+}
+
+func (h *Handler) OAuthStartHandler(c *fiber.Ctx) error {
+	provider, ok := h.OAuthProviders.Get(c.Params("provider"))
+	if !ok {
+		return appError.NewNotFoundErr("Identity provider not found").WithCode(appError.CodeProviderNotFound)
+	}
+
+	flow, err := oauth.NewFlow(provider.Name())
+	if err != nil {
+		return err
+	}
+
+	authURL, err := provider.AuthCodeURL(c.UserContext(), flow)
+	if err != nil {
+		return appError.New(err, "Identity provider is unavailable", http.StatusBadGateway).WithCode(appError.CodeProviderUnavailable)
+	}
+
+	state, err := oauth.SignFlow(flow)
+	if err != nil {
+		return err
+	}
+
+	cookie := oauthStateCookie(c, state)
+	cookie.MaxAge = int(oauth.FlowTTL / time.Second)
+	c.Cookie(cookie)
+
+	return c.Redirect(authURL, http.StatusFound)
+}
+
+// OAuthCallbackHandler finishes the provider login and sends the browser back
+// to the SPA with the outcome in the URL fragment, which never reaches server
+// logs or the Referer header.
+func (h *Handler) OAuthCallbackHandler(c *fiber.Ctx) error {
+	// The state is single use, whatever the outcome.
+	expired := oauthStateCookie(c, "")
+	expired.Expires = time.Unix(0, 0)
+	c.Cookie(expired)
+
+	provider, ok := h.OAuthProviders.Get(c.Params("provider"))
+	if !ok {
+		return appError.NewNotFoundErr("Identity provider not found").WithCode(appError.CodeProviderNotFound)
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		return h.oauthRedirect(c, url.Values{"error": {providerErr}})
+	}
+
+	flow, err := oauth.ParseFlow(c.Cookies(oauth.StateCookieName), provider.Name(), c.Query("state"))
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "rejected oauth callback", "provider", provider.Name(), "error", err)
+		return h.oauthRedirect(c, url.Values{"error": {"invalid_state"}})
+	}
+
+	identity, err := provider.Exchange(c.UserContext(), c.Query("code"), flow)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "oauth code exchange failed", "provider", provider.Name(), "error", err)
+		return h.oauthRedirect(c, url.Values{"error": {"exchange_failed"}})
+	}
+
+	result, err := h.UserService.LoginWithIdentity(c.UserContext(), &user.ExternalIdentity{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		FirstName:     identity.FirstName,
+		LastName:      identity.LastName,
+	}, clientInfo(c))
+	if err != nil {
+		h.Metrics.ObserveLogin(metrics.LoginOAuth, metrics.LoginResult(err))
+		slog.WarnContext(c.UserContext(), "oauth login failed", "provider", provider.Name(), "error", err)
+		return h.oauthRedirect(c, url.Values{"error": {oauthErrorCode(err)}})
+	}
+
+	if result.TwoFactorRequired {
+		h.Metrics.ObserveLogin(metrics.LoginOAuth, metrics.LoginTwoFactorRequired)
+		return h.oauthRedirect(c, url.Values{"challengeToken": {result.ChallengeToken}})
+	}
+
+	h.Metrics.ObserveLogin(metrics.LoginOAuth, metrics.LoginSuccess)
+
+	if h.AuthCookies.Enabled {
+		if _, err := h.AuthCookies.SetSessionCookies(c, result.Token); err != nil {
+			return err
+		}
+
+		return h.oauthRedirect(c, url.Values{"session": {"cookie"}})
+	}
+
+	return h.oauthRedirect(c, url.Values{"token": {result.Token}})
+}
+
+func (h *Handler) oauthRedirect(c *fiber.Ctx, fragment url.Values) error {
+	return c.Redirect(h.OAuthProviders.SuccessRedirect+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// oauthStateCookie holds the signed flow state between the start and the
+// callback. It is scoped to the OAuth routes, so expiring it needs the same
+// Path.
+func oauthStateCookie(c *fiber.Ctx, value string) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     oauth.StateCookieName,
+		Value:    value,
+		Path:     "/api/v1/auth/oauth",
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	}
+}
+
+// oauthErrorCode is the stable code of a failed login, which is all the SPA
+// gets to see. The error itself is only logged.
+func oauthErrorCode(err error) string {
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) {
+		return appError.CodeOf(appErr)
+	}
+
+	return appError.CodeInternal
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/openapi"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The document only depends on code, so it is rendered once.
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	return openapi.JSON(openapi.Operations)
+})
+
+// OpenAPIHandler serves the OpenAPI document of the API.
+func (h *Handler) OpenAPIHandler(c *fiber.Ctx) error {
+	document, err := openAPIDocument()
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(document)
+}
+
+// DocsHandler serves Swagger UI for the OpenAPI document.
+func (h *Handler) DocsHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(openapi.DocsPage)
+}
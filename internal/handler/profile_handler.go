@@ -0,0 +1,194 @@
+package handler
+
+import (
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) GetProfileHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	profile, err := h.UserService.GetProfile(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Successfully get profile"),
+		utils.WithData(profile),
+	)
+}
+
+func (h *Handler) UpdateProfileHandler(c *fiber.Ctx) error {
+	var dto user.UpdateProfileDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	profile, err := h.UserService.UpdateProfile(c.UserContext(), userId, &dto)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Profile updated"),
+		utils.WithData(profile),
+	)
+}
+
+func (h *Handler) ChangePasswordHandler(c *fiber.Ctx) error {
+	var dto user.ChangePasswordDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	sessionId, _ := c.Locals("sessionId").(string)
+
+	if err := h.UserService.ChangePassword(c.UserContext(), userId, sessionId, &dto); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Password changed"),
+	)
+}
+
+func (h *Handler) ChangeEmailHandler(c *fiber.Ctx) error {
+	var dto user.ChangeEmailDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	if err := h.UserService.RequestEmailChange(c.UserContext(), userId, &dto); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Check your new inbox to confirm the change"),
+		utils.WithStatus(http.StatusAccepted),
+	)
+}
+
+func (h *Handler) ConfirmEmailChangeHandler(c *fiber.Ctx) error {
+	var dto user.ConfirmEmailChangeDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	if err := h.UserService.ConfirmEmailChange(c.UserContext(), &dto); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Email address updated"),
+	)
+}
+
+func (h *Handler) GetSessionsHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	sessionId, _ := c.Locals("sessionId").(string)
+
+	sessions, err := h.UserService.GetSessions(c.UserContext(), userId, sessionId)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Successfully get sessions"),
+		utils.WithData(sessions),
+	)
+}
+
+func (h *Handler) RevokeSessionHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	sessionId := c.Params("id")
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return appError.NewNotFoundErr("Session not found").WithCode(appError.CodeSessionNotFound)
+	}
+
+	if err := h.UserService.RevokeSession(c.UserContext(), userId, sessionId); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Session revoked"),
+	)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/token"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) CreateTokenHandler(c *fiber.Ctx) error {
+	var dto token.CreateTokenDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	created, err := h.TokenService.CreateToken(c.UserContext(), userId, &dto)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Token created. Copy it now, it won't be shown again"),
+		utils.WithStatus(http.StatusCreated),
+		utils.WithData(created),
+	)
+}
+
+func (h *Handler) GetTokensHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	tokens, err := h.TokenService.GetTokens(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Successfully get tokens"),
+		utils.WithData(tokens),
+	)
+}
+
+func (h *Handler) RevokeTokenHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	// An id that isn't a UUID can't name a token, and would otherwise fail
+	// in the database.
+	tokenId := c.Params("id")
+	if _, err := uuid.Parse(tokenId); err != nil {
+		return appError.NewNotFoundErr("Token not found").WithCode(appError.CodeTokenNotFound)
+	}
+
+	if err := h.TokenService.RevokeToken(c.UserContext(), userId, tokenId); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Token revoked"),
+	)
+}
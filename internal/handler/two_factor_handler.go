@@ -0,0 +1,118 @@
+package handler
+
+import (
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/metrics"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) SetupTwoFactorHandler(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	setup, err := h.UserService.SetupTwoFactor(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Scan the otpauth URI and confirm with a code"),
+		utils.WithData(setup),
+	)
+}
+
+func (h *Handler) ConfirmTwoFactorHandler(c *fiber.Ctx) error {
+	var dto user.ConfirmTwoFactorDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	codes, err := h.UserService.ConfirmTwoFactor(c.UserContext(), userId, &dto)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Two-factor authentication enabled"),
+		utils.WithData(user.RecoveryCodesResponse{RecoveryCodes: codes}),
+	)
+}
+
+func (h *Handler) VerifyTwoFactorHandler(c *fiber.Ctx) error {
+	var dto user.VerifyTwoFactorDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	token, err := h.UserService.VerifyTwoFactor(c.UserContext(), &dto, clientInfo(c))
+	h.Metrics.ObserveLogin(metrics.LoginTwoFactor, metrics.LoginResult(err))
+	if err != nil {
+		return err
+	}
+
+	return h.sessionResponse(c, token)
+}
+
+func (h *Handler) DisableTwoFactorHandler(c *fiber.Ctx) error {
+	var dto user.DisableTwoFactorDto
+
+	if err := c.BodyParser(&dto); err != nil {
+		return invalidBody(c, err)
+	}
+
+	if errors := utils.ValidateStruct(c, dto); errors != nil {
+		return utils.NewResponse(
+			c,
+			utils.WithMessage("Bad Request"),
+			utils.WithStatus(http.StatusBadRequest),
+			utils.WithError(errors),
+		)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	if err := h.UserService.DisableTwoFactor(c.UserContext(), userId, &dto); err != nil {
+		return err
+	}
+
+	return utils.NewResponse(
+		c,
+		utils.WithMessage("Two-factor authentication disabled"),
+	)
+}
@@ -0,0 +1,107 @@
+// Package health backs the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// checkTimeout bounds every check so a hanging dependency fails the probe
+// instead of timing it out.
+const checkTimeout = 2 * time.Second
+
+// Check is one dependency readiness depends on.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type Checker struct {
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// Shutdown makes every following readiness check fail, so load balancers
+// stop sending traffic before the server stops accepting it.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs every check concurrently.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make([]Result, len(c.checks))}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusUnavailable
+		report.Checks = []Result{{Name: "shutdown", Status: StatusUnavailable}}
+		return report
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+
+	return report
+}
+
+// run only reports whether check passed. The probe is public, so why it
+// failed goes to the log instead of the response.
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusUnavailable
+		slog.Warn("readiness check failed", "check", check.Name, "error", err)
+	}
+
+	return result
+}
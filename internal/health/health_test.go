@@ -0,0 +1,95 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReadyReportsEveryCheck(t *testing.T) {
+	checker := NewChecker(
+		Check{Name: "up", Run: func(context.Context) error { return nil }},
+		Check{Name: "down", Run: func(context.Context) error { return errors.New("boom") }},
+	)
+
+	report := checker.Ready(context.Background())
+	if report.Ready() {
+		t.Fatal("expected a failing check to make the report not ready")
+	}
+
+	if report.Checks[0].Status != StatusOK || report.Checks[1].Status != StatusUnavailable {
+		t.Errorf("unexpected checks: %+v", report.Checks)
+	}
+}
+
+func TestReadyFailsAfterShutdown(t *testing.T) {
+	checker := NewChecker(Check{Name: "up", Run: func(context.Context) error { return nil }})
+
+	if !checker.Ready(context.Background()).Ready() {
+		t.Fatal("expected the checker to be ready")
+	}
+
+	checker.Shutdown()
+
+	if checker.Ready(context.Background()).Ready() {
+		t.Fatal("expected the checker to be not ready after shutdown")
+	}
+}
+
+func TestRedisCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	var commands []string
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			// Only the arguments of the RESP arrays matter here.
+			if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "$") {
+				continue
+			}
+
+			command := strings.TrimSpace(line)
+			commands = append(commands, command)
+
+			switch command {
+			case "AUTH":
+				continue
+			case "PING":
+				conn.Write([]byte("+PONG\r\n"))
+			default:
+				conn.Write([]byte("+OK\r\n"))
+			}
+		}
+	}()
+
+	check, err := Redis("redis://:s3cret@" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(commands, " ") != "AUTH s3cret PING" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Redis checks a Redis server given as redis://[user:password@]host:port
+// by sending PING, authenticating first when the URL has a password. It
+// speaks the protocol directly, the check is all we need a client for.
+func Redis(rawURL string) (Check, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Check{}, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	if u.Scheme != "redis" || u.Host == "" {
+		return Check{}, fmt.Errorf("invalid REDIS_URL, expected redis://host:port")
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	var auth []string
+	if password, ok := u.User.Password(); ok {
+		auth = []string{"AUTH", password}
+		if username := u.User.Username(); username != "" {
+			auth = []string{"AUTH", username, password}
+		}
+	}
+
+	return Check{
+		Name: "redis",
+		Run: func(ctx context.Context) error {
+			return pingRedis(ctx, addr, auth)
+		},
+	}, nil
+}
+
+func pingRedis(ctx context.Context, addr string, auth []string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	if auth != nil {
+		if _, err := command(conn, reader, auth...); err != nil {
+			return err
+		}
+	}
+
+	reply, err := command(conn, reader, "PING")
+	if err != nil {
+		return err
+	}
+
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected reply to PING: %q", reply)
+	}
+
+	return nil
+}
+
+// command sends args as a RESP array and reads a simple string reply.
+func command(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return "", fmt.Errorf("redis: %s", line[1:])
+	}
+
+	return strings.TrimPrefix(line, "+"), nil
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"fmt"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+)
+
+// Supported lists the locales messages are translated to. The first one is
+// the fallback and the language the messages are written in.
+var Supported = []language.Tag{language.English, language.Indonesian}
+
+var (
+	universal = ut.New(en.New(), en.New(), id.New())
+	matcher   = language.NewMatcher(Supported)
+)
+
+func init() {
+	for locale, catalog := range catalogs {
+		trans, _ := universal.GetTranslator(locale)
+
+		for key, text := range catalog.messages {
+			must(trans.Add(key, text, false))
+		}
+
+		for key, forms := range catalog.plurals {
+			for rule, text := range forms {
+				must(trans.AddCardinal(key, text, rule, false))
+			}
+		}
+
+		must(trans.VerifyTranslations())
+	}
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language
+// header, so "id-ID,id;q=0.9" gets Indonesian. Anything else gets English.
+func Negotiate(acceptLanguage string) ut.Translator {
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+
+	trans, _ := universal.GetTranslator(base.String())
+	return trans
+}
+
+// Translators returns the translator of every supported locale.
+func Translators() []ut.Translator {
+	translators := make([]ut.Translator, len(Supported))
+	for i, tag := range Supported {
+		translators[i], _ = universal.GetTranslator(tag.String())
+	}
+
+	return translators
+}
+
+// Message translates an English message, which doubles as the key. Messages
+// without a translation, like ones carrying a parser error, stay English.
+func Message(trans ut.Translator, message string) string {
+	if translated, err := trans.T(message); err == nil {
+		return translated
+	}
+
+	return message
+}
+
+// Count renders n with the plural form of key, e.g. "8 characters".
+func Count(trans ut.Translator, key string, n float64, param string) string {
+	counted, err := trans.C(key, n, 0, param)
+	if err != nil {
+		return param
+	}
+
+	return counted
+}
+
+type catalog struct {
+	messages map[string]string
+	plurals  map[string]map[locales.PluralRule]string
+}
+
+func must(err error) {
+	if err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+}
@@ -0,0 +1,72 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID,id;q=0.9,en;q=0.8", "id"},
+		{"en-US,id;q=0.5", "en"},
+		{"fr-FR,id;q=0.7", "id"},
+		{"de", "en"},
+	}
+
+	for _, tc := range cases {
+		if got := Negotiate(tc.header).Locale(); got != tc.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	id := Negotiate("id")
+
+	if got := Message(id, "Application not found"); got != "Lamaran tidak ditemukan" {
+		t.Errorf("unexpected translation %q", got)
+	}
+
+	if got := Message(id, "strconv.Atoi: parsing \"x\": invalid syntax"); got != "strconv.Atoi: parsing \"x\": invalid syntax" {
+		t.Errorf("expected an unknown message to stay as is, got %q", got)
+	}
+
+	if got := Count(Negotiate("en"), UnitCharacters, 1, "1"); got != "1 character" {
+		t.Errorf("unexpected count %q", got)
+	}
+
+	if got := Count(Negotiate("en"), UnitCharacters, 8, "8"); got != "8 characters" {
+		t.Errorf("unexpected count %q", got)
+	}
+}
+
+// Keys, unlike messages keyed by their English text, have no fallback, so
+// every locale has to list them.
+func TestCatalogsHaveEveryKey(t *testing.T) {
+	keys := catalogs["en"]
+
+	for locale, catalog := range catalogs {
+		trans, _ := universal.GetTranslator(locale)
+
+		for key := range keys.messages {
+			if _, ok := catalog.messages[key]; !ok {
+				t.Errorf("%s: missing message %q", locale, key)
+			}
+		}
+
+		for key := range keys.plurals {
+			for _, rule := range trans.PluralsCardinal() {
+				if _, ok := catalog.plurals[key][rule]; !ok {
+					t.Errorf("%s: missing %s form of %q", locale, rule, key)
+				}
+			}
+		}
+	}
+
+	id := Negotiate("id")
+	if got, _ := id.T(TokenMissingScope, "applications:write"); got != "token tidak memiliki cakupan applications:write" {
+		t.Errorf("unexpected translation %q", got)
+	}
+}
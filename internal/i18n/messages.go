@@ -0,0 +1,154 @@
+package i18n
+
+import "github.com/go-playground/locales"
+
+// Validation messages are keyed by validator tag. They leave out the field,
+// which the response names separately.
+const (
+	ValidationRequired        = "validation.required"
+	ValidationRequiredWithout = "validation.required_without"
+	ValidationEmail           = "validation.email"
+	ValidationURL             = "validation.url"
+	ValidationNumeric         = "validation.numeric"
+	ValidationOneOf           = "validation.oneof"
+	ValidationMin             = "validation.min"
+	ValidationMax             = "validation.max"
+	ValidationLen             = "validation.len"
+	ValidationInvalid         = "validation.invalid"
+	// ValidationNotNull isn't a validator tag, it is for null members of a
+	// merge patch.
+	ValidationNotNull = "validation.not_null"
+
+	// Password policy limits, with the counted limit as {0}.
+	PasswordMinLength = "password.min_length"
+	PasswordMaxLength = "password.max_length"
+
+	// TokenMissingScope names the scope a personal access token lacks as {0}.
+	TokenMissingScope = "token.missing_scope"
+
+	// Units for min, max and len, counted with Count.
+	UnitCharacters = "unit.characters"
+	UnitItems      = "unit.items"
+)
+
+// catalogs holds every message by locale. Error messages are keyed by their
+// English text, so only the other locales list them; a message missing here
+// is sent in English.
+var catalogs = map[string]catalog{
+	"en": {
+		messages: map[string]string{
+			ValidationRequired:        "is required",
+			ValidationRequiredWithout: "is required when {0} is empty",
+			ValidationEmail:           "must be a valid email address",
+			ValidationURL:             "must be a valid URL",
+			ValidationNumeric:         "must be a number",
+			ValidationOneOf:           "must be one of {0}",
+			ValidationMin:             "must be at least {0}",
+			ValidationMax:             "must be at most {0}",
+			ValidationLen:             "must be exactly {0}",
+			ValidationInvalid:         "is invalid",
+			ValidationNotNull:         "must not be null",
+			PasswordMinLength:         "password must be at least {0}",
+			PasswordMaxLength:         "password must be at most {0}",
+			TokenMissingScope:         "token is missing the {0} scope",
+		},
+		plurals: map[string]map[locales.PluralRule]string{
+			UnitCharacters: {
+				locales.PluralRuleOne:   "{0} character",
+				locales.PluralRuleOther: "{0} characters",
+			},
+			UnitItems: {
+				locales.PluralRuleOne:   "{0} item",
+				locales.PluralRuleOther: "{0} items",
+			},
+		},
+	},
+	"id": {
+		messages: map[string]string{
+			ValidationRequired:        "wajib diisi",
+			ValidationRequiredWithout: "wajib diisi jika {0} kosong",
+			ValidationEmail:           "harus berupa alamat email yang valid",
+			ValidationURL:             "harus berupa URL yang valid",
+			ValidationNumeric:         "harus berupa angka",
+			ValidationOneOf:           "harus salah satu dari {0}",
+			ValidationMin:             "minimal {0}",
+			ValidationMax:             "maksimal {0}",
+			ValidationLen:             "harus tepat {0}",
+			ValidationInvalid:         "tidak valid",
+			ValidationNotNull:         "tidak boleh null",
+			PasswordMinLength:         "kata sandi minimal {0}",
+			PasswordMaxLength:         "kata sandi maksimal {0}",
+			TokenMissingScope:         "token tidak memiliki cakupan {0}",
+
+			// Problem titles.
+			"Bad Request":              "Permintaan Tidak Valid",
+			"Unauthorized":             "Tidak Terautentikasi",
+			"Forbidden":                "Akses Ditolak",
+			"Not Found":                "Tidak Ditemukan",
+			"Method Not Allowed":       "Metode Tidak Diizinkan",
+			"Conflict":                 "Konflik",
+			"Request Entity Too Large": "Permintaan Terlalu Besar",
+			"Too Many Requests":        "Terlalu Banyak Permintaan",
+			"Internal Server Error":    "Kesalahan Server Internal",
+			"Bad Gateway":              "Kesalahan Gateway",
+			"Service Unavailable":      "Layanan Tidak Tersedia",
+			"Precondition Failed":      "Prasyarat Gagal",
+
+			"Application id is missing":                                "ID lamaran tidak ada",
+			"Application not found":                                    "Lamaran tidak ditemukan",
+			"Confirm the deletion by entering your email address":      "Konfirmasi penghapusan dengan memasukkan alamat email Anda",
+			"Cookie auth mode is disabled":                             "Mode autentikasi cookie tidak aktif",
+			"Email address is not verified by the identity provider":   "Alamat email belum diverifikasi oleh penyedia identitas",
+			"Email already exists":                                     "Email sudah terdaftar",
+			"Email confirmation link is invalid or has expired":        "Tautan konfirmasi email tidak valid atau sudah kedaluwarsa",
+			"Identity is already linked to an account":                 "Identitas sudah terhubung dengan sebuah akun",
+			"Identity provider is unavailable":                         "Penyedia identitas sedang tidak tersedia",
+			"Identity provider not found":                              "Penyedia identitas tidak ditemukan",
+			"Invalid credentials":                                      "Email atau kata sandi salah",
+			"Invalid input provided":                                   "Data yang dikirim tidak valid",
+			"Invalid or expired challenge token":                       "Token verifikasi tidak valid atau sudah kedaluwarsa",
+			"Invalid two-factor code":                                  "Kode autentikasi dua faktor salah",
+			"No application IDs provided":                              "Tidak ada ID lamaran yang dikirim",
+			"No applications found to delete":                          "Tidak ada lamaran yang dapat dihapus",
+			"No applications found to update":                          "Tidak ada lamaran yang dapat diperbarui",
+			"Resource not found":                                       "Data tidak ditemukan",
+			"Route not found":                                          "Rute tidak ditemukan",
+			"Session not found":                                        "Sesi tidak ditemukan",
+			"The request body is invalid":                              "Isi permintaan tidak valid",
+			"The request took too long":                                "Permintaan memakan waktu terlalu lama",
+			"Token not found":                                          "Token tidak ditemukan",
+			"Too many failed two-factor attempts, try again later":     "Terlalu banyak percobaan autentikasi dua faktor yang gagal, coba lagi nanti",
+			"Two-factor authentication is already enabled":             "Autentikasi dua faktor sudah aktif",
+			"Two-factor authentication is not enabled":                 "Autentikasi dua faktor belum aktif",
+			"Two-factor authentication setup has not been started":     "Penyiapan autentikasi dua faktor belum dimulai",
+			"Unauthorized access":                                      "Akses tidak diizinkan",
+			"invalid request body":                                     "Isi permintaan tidak valid",
+			"invalid query parameters":                                 "Parameter kueri tidak valid",
+			"missing or invalid CSRF token":                            "Token CSRF tidak ada atau tidak valid",
+			"Idempotency-Key is too long":                              "Idempotency-Key terlalu panjang",
+			"Idempotency-Key was already used for a different request": "Idempotency-Key sudah dipakai untuk permintaan lain",
+			"A request with this Idempotency-Key is still in progress": "Permintaan dengan Idempotency-Key ini masih diproses",
+			"Unprocessable Entity":                                     "Permintaan Tidak Dapat Diproses",
+			"Merge patch must be a JSON object":                        "Merge patch harus berupa objek JSON",
+			"Application ids must be UUIDs":                            "ID lamaran harus berupa UUID",
+
+			"Application was changed by another request, reload it and try again":           "Lamaran sudah diubah oleh permintaan lain, muat ulang lalu coba lagi",
+			"If-Match is required, send the ETag of the application or * to skip the check": "If-Match wajib diisi, kirim ETag lamaran atau * untuk melewati pemeriksaan",
+			"Versions can only be given for applications in applicationIds":                 "Versi hanya boleh diberikan untuk lamaran di applicationIds",
+			"Confirm the change by entering your current email address":                     "Konfirmasi perubahan dengan memasukkan alamat email Anda saat ini",
+
+			// Password policy.
+			"password must not be the same as your email or name":                                              "kata sandi tidak boleh sama dengan email atau nama Anda",
+			"password appears in a list of common or breached passwords":                                       "kata sandi termasuk dalam daftar kata sandi umum atau yang pernah bocor",
+			"password is too easy to guess, use a longer passphrase or avoid common words, names and patterns": "kata sandi terlalu mudah ditebak, gunakan frasa yang lebih panjang atau hindari kata umum, nama dan pola",
+		},
+		plurals: map[string]map[locales.PluralRule]string{
+			UnitCharacters: {
+				locales.PluralRuleOther: "{0} karakter",
+			},
+			UnitItems: {
+				locales.PluralRuleOther: "{0} item",
+			},
+		},
+	},
+}
@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryIdempotencyRepository is an in-memory Repository for tests. It
+// mirrors the Postgres behaviour.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[memoryKey]*memoryRecord
+}
+
+type memoryRecord struct {
+	Record
+	leaseId string
+}
+
+type memoryKey struct {
+	userId  string
+	keyHash string
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[memoryKey]*memoryRecord),
+	}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(_ context.Context, userId, keyHash, fingerprint, leaseId string, expiresAt time.Time) (*Record, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{userId, keyHash}
+
+	if record, ok := r.records[key]; ok && record.ExpiresAt.After(time.Now()) {
+		return cloneRecord(&record.Record), false, nil
+	}
+
+	r.records[key] = &memoryRecord{
+		Record:  Record{Fingerprint: fingerprint, ExpiresAt: expiresAt},
+		leaseId: leaseId,
+	}
+
+	return nil, true, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(_ context.Context, userId, keyHash, leaseId string, response *Response, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[memoryKey{userId, keyHash}]
+	if !ok || record.leaseId != leaseId || record.Response != nil {
+		return ErrLeaseLost
+	}
+
+	record.Response = &Response{
+		StatusCode:  response.StatusCode,
+		ContentType: response.ContentType,
+		Body:        slices.Clone(response.Body),
+	}
+	record.ExpiresAt = expiresAt
+
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(_ context.Context, userId, keyHash, leaseId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{userId, keyHash}
+	if record, ok := r.records[key]; ok && record.leaseId == leaseId && record.Response == nil {
+		delete(r.records, key)
+	}
+
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) PurgeExpired(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(before) {
+			delete(r.records, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func cloneRecord(record *Record) *Record {
+	clone := *record
+	if record.Response != nil {
+		response := *record.Response
+		response.Body = slices.Clone(response.Body)
+		clone.Response = &response
+	}
+
+	return &clone
+}
@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Reserve inserts the key, or takes over a record that has expired but not
+// been purged yet. The primary key makes concurrent requests with the same
+// key race for a single row, so only one of them runs.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userId, keyHash, fingerprint, leaseId string, expiresAt time.Time) (*Record, bool, error) {
+	reserveQuery := `
+		insert into idempotency_keys (user_id, key_hash, fingerprint, lease_id, expires_at)
+		values ($1, $2, $3, $4, $5)
+		on conflict (user_id, key_hash) do update
+		set fingerprint = excluded.fingerprint,
+			lease_id = excluded.lease_id,
+			status_code = null,
+			content_type = null,
+			response_body = null,
+			created_at = now(),
+			expires_at = excluded.expires_at
+		where idempotency_keys.expires_at <= now()
+	`
+
+	fetchQuery := `
+		select fingerprint, status_code, content_type, response_body, expires_at
+		from idempotency_keys
+		where user_id = $1 and key_hash = $2
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	for attempt := 0; attempt < maxReserveAttempts; attempt++ {
+		tag, err := r.db.Exec(ctx, reserveQuery, userId, keyHash, fingerprint, leaseId, expiresAt)
+		if err != nil {
+			return nil, false, appError.WrapInternalServerError(err)
+		}
+
+		if tag.RowsAffected() == 1 {
+			return nil, true, nil
+		}
+
+		record := new(Record)
+		var statusCode *int
+		var contentType *string
+		var body []byte
+
+		err = r.db.QueryRow(ctx, fetchQuery, userId, keyHash).Scan(
+			&record.Fingerprint,
+			&statusCode,
+			&contentType,
+			&body,
+			&record.ExpiresAt,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Purged between the two queries.
+			continue
+		}
+		if err != nil {
+			return nil, false, appError.WrapInternalServerError(err)
+		}
+
+		if statusCode != nil {
+			record.Response = &Response{StatusCode: *statusCode, Body: body}
+			if contentType != nil {
+				record.Response.ContentType = *contentType
+			}
+		}
+
+		return record, false, nil
+	}
+
+	return nil, false, errInProgress()
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, userId, keyHash, leaseId string, response *Response, expiresAt time.Time) error {
+	updateQuery := `
+		update idempotency_keys
+		set status_code = $1, content_type = $2, response_body = $3, expires_at = $4
+		where user_id = $5 and key_hash = $6 and lease_id = $7 and status_code is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	tag, err := r.db.Exec(ctx, updateQuery, response.StatusCode, response.ContentType, response.Body, expiresAt, userId, keyHash, leaseId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, userId, keyHash, leaseId string) error {
+	deleteQuery := `
+		delete from idempotency_keys
+		where user_id = $1 and key_hash = $2 and lease_id = $3 and status_code is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, deleteQuery, userId, keyHash, leaseId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := `delete from idempotency_keys where expires_at <= $1`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	tag, err := r.db.Exec(ctx, deleteQuery, before)
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
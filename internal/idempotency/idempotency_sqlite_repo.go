@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+)
+
+// Reserve inserts the key, or takes over a record that has expired but not
+// been purged yet.
+func (r *SQLiteIdempotencyRepository) Reserve(ctx context.Context, userId, keyHash, fingerprint, leaseId string, expiresAt time.Time) (*Record, bool, error) {
+	reserveQuery := `
+		insert into idempotency_keys (user_id, key_hash, fingerprint, lease_id, created_at, expires_at)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (user_id, key_hash) do update
+		set fingerprint = excluded.fingerprint,
+			lease_id = excluded.lease_id,
+			status_code = null,
+			content_type = null,
+			response_body = null,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at
+		where idempotency_keys.expires_at <= excluded.created_at
+	`
+
+	fetchQuery := `
+		select fingerprint, status_code, content_type, response_body, expires_at
+		from idempotency_keys
+		where user_id = $1 and key_hash = $2
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	for attempt := 0; attempt < maxReserveAttempts; attempt++ {
+		result, err := r.db.ExecContext(ctx, reserveQuery, userId, keyHash, fingerprint, leaseId, storage.SQLiteTime(time.Now()), storage.SQLiteTime(expiresAt))
+		if err != nil {
+			return nil, false, appError.WrapInternalServerError(err)
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return nil, false, appError.WrapInternalServerError(err)
+		} else if affected == 1 {
+			return nil, true, nil
+		}
+
+		record := new(Record)
+		var statusCode sql.NullInt64
+		var contentType sql.NullString
+		var body []byte
+
+		err = r.db.QueryRowContext(ctx, fetchQuery, userId, keyHash).Scan(
+			&record.Fingerprint,
+			&statusCode,
+			&contentType,
+			&body,
+			&record.ExpiresAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Purged between the two queries.
+			continue
+		}
+		if err != nil {
+			return nil, false, appError.WrapInternalServerError(err)
+		}
+
+		if statusCode.Valid {
+			record.Response = &Response{
+				StatusCode:  int(statusCode.Int64),
+				ContentType: contentType.String,
+				Body:        body,
+			}
+		}
+
+		return record, false, nil
+	}
+
+	return nil, false, errInProgress()
+}
+
+func (r *SQLiteIdempotencyRepository) Complete(ctx context.Context, userId, keyHash, leaseId string, response *Response, expiresAt time.Time) error {
+	updateQuery := `
+		update idempotency_keys
+		set status_code = $1, content_type = $2, response_body = $3, expires_at = $4
+		where user_id = $5 and key_hash = $6 and lease_id = $7 and status_code is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, updateQuery, response.StatusCode, response.ContentType, response.Body, storage.SQLiteTime(expiresAt), userId, keyHash, leaseId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return appError.WrapInternalServerError(err)
+	} else if affected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (r *SQLiteIdempotencyRepository) Release(ctx context.Context, userId, keyHash, leaseId string) error {
+	deleteQuery := `
+		delete from idempotency_keys
+		where user_id = $1 and key_hash = $2 and lease_id = $3 and status_code is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, deleteQuery, userId, keyHash, leaseId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteIdempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := `delete from idempotency_keys where expires_at <= $1`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, storage.SQLiteTime(before))
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return purged, nil
+}
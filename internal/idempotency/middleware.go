@@ -0,0 +1,184 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	// maxReserveAttempts bounds how often Reserve retries when the record
+	// it collided with is purged before it can be read.
+	maxReserveAttempts = 2
+)
+
+type Middleware struct {
+	repo Repository
+	ttl  time.Duration
+	// lease is how long a key stays reserved while its request runs. If
+	// the process dies mid-request, retries are refused for no longer.
+	lease time.Duration
+}
+
+// NewMiddleware keeps responses for ttl. lease should be the longest a
+// request can take.
+func NewMiddleware(repo Repository, ttl, lease time.Duration) *Middleware {
+	return &Middleware{
+		repo:  repo,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// Handler makes a route safe to retry when the client sends an
+// Idempotency-Key. The first request with a key runs and its response is
+// stored; retries with the same key and request get that response back,
+// while the key on a different request is refused. It has to run after the
+// auth middleware, since keys are scoped to the user.
+func (m *Middleware) Handler(c *fiber.Ctx) error {
+	key := c.Get(HeaderKey)
+	if key == "" {
+		return c.Next()
+	}
+
+	if len(key) > maxKeyLength {
+		return appError.NewBadRequestError("Idempotency-Key is too long").WithCode(appError.CodeIdempotencyKeyInvalid)
+	}
+
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return appError.ErrUnauthorized
+	}
+
+	keyHash := hash([]byte(key))
+	fingerprint := hash([]byte(c.Method()+" "+c.Path()+"\n"), c.Body())
+
+	leaseId := uuid.NewString()
+
+	record, reserved, err := m.repo.Reserve(c.UserContext(), userId, keyHash, fingerprint, leaseId, time.Now().Add(m.lease))
+	if err != nil {
+		return err
+	}
+
+	if !reserved {
+		return replay(c, record, fingerprint)
+	}
+
+	// The request context may be done by now, which mustn't leave the key
+	// reserved.
+	ctx := context.WithoutCancel(c.UserContext())
+
+	// Unless a response gets stored, the key is released so that a retry
+	// runs again. That includes a panic further down, which the recover
+	// middleware only catches outside of this one.
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := m.repo.Release(ctx, userId, keyHash, leaseId); err != nil {
+			slog.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+		}
+	}()
+
+	if err := c.Next(); err != nil {
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			return err
+		}
+	}
+
+	status := c.Response().StatusCode()
+	if status >= http.StatusInternalServerError {
+		return nil
+	}
+
+	err = m.repo.Complete(ctx, userId, keyHash, leaseId, &Response{
+		StatusCode:  status,
+		ContentType: string(c.Response().Header.ContentType()),
+		Body:        append([]byte(nil), c.Response().Body()...),
+	}, time.Now().Add(m.ttl))
+	if errors.Is(err, ErrLeaseLost) {
+		slog.WarnContext(ctx, "idempotency key lease expired before the response was stored")
+		return nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+		return nil
+	}
+
+	completed = true
+
+	return nil
+}
+
+func replay(c *fiber.Ctx, record *Record, fingerprint string) error {
+	if record.Fingerprint != fingerprint {
+		return appError.New(
+			errors.New("idempotency key reused"),
+			"Idempotency-Key was already used for a different request",
+			http.StatusUnprocessableEntity,
+		).WithCode(appError.CodeIdempotencyKeyReused)
+	}
+
+	if record.Response == nil {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return errInProgress()
+	}
+
+	c.Set(HeaderReplayed, "true")
+	c.Set(fiber.HeaderContentType, record.Response.ContentType)
+
+	return c.Status(record.Response.StatusCode).Send(record.Response.Body)
+}
+
+func errInProgress() *appError.AppError {
+	return appError.New(
+		errors.New("idempotent request in progress"),
+		"A request with this Idempotency-Key is still in progress",
+		http.StatusConflict,
+	).WithCode(appError.CodeIdempotencyInProgress)
+}
+
+// RunPurger deletes expired keys once per interval, until ctx is cancelled.
+func (m *Middleware) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := m.repo.PurgeExpired(ctx, time.Now())
+		if err != nil {
+			slog.Error("failed to purge idempotency keys", "error", err)
+		} else if purged > 0 {
+			slog.Info("purged idempotency keys", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
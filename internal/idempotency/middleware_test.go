@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	var fail, panics atomic.Bool
+
+	m := NewMiddleware(NewMemoryIdempotencyRepository(), time.Hour, time.Minute)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(false)})
+	app.Use(recover.New())
+	app.Post("/applications", func(c *fiber.Ctx) error {
+		c.Locals("userId", c.Get("X-User"))
+		return c.Next()
+	}, m.Handler, func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		if panics.Load() {
+			panic("boom")
+		}
+		if fail.Load() {
+			return fiber.ErrInternalServerError
+		}
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"call": n})
+	})
+
+	send := func(user, key, body string) (*http.Response, string) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/applications", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set(HeaderKey, key)
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	first, firstBody := send("user-1", "key-1", `{"companyName":"Acme"}`)
+	if first.StatusCode != http.StatusCreated || first.Header.Get(HeaderReplayed) != "" {
+		t.Fatalf("expected the first request to run, got %d", first.StatusCode)
+	}
+
+	retry, retryBody := send("user-1", "key-1", `{"companyName":"Acme"}`)
+	if retry.StatusCode != http.StatusCreated || retryBody != firstBody || retry.Header.Get(HeaderReplayed) != "true" {
+		t.Fatalf("expected the stored response, got %d %s", retry.StatusCode, retryBody)
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls.Load())
+	}
+
+	if resp, _ := send("user-1", "key-1", `{"companyName":"Other"}`); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused key, got %d", resp.StatusCode)
+	}
+
+	if resp, _ := send("user-2", "key-1", `{"companyName":"Acme"}`); resp.StatusCode != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("expected keys to be scoped to the user, got %d", resp.StatusCode)
+	}
+
+	send("user-1", "", `{"companyName":"Acme"}`)
+	send("user-1", "", `{"companyName":"Acme"}`)
+	if calls.Load() != 4 {
+		t.Errorf("expected requests without a key to always run, ran %d times", calls.Load())
+	}
+
+	fail.Store(true)
+	send("user-1", "key-2", `{}`)
+	fail.Store(false)
+
+	if resp, _ := send("user-1", "key-2", `{}`); resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected a retry after a server error to run again, got %d", resp.StatusCode)
+	}
+
+	panics.Store(true)
+	send("user-1", "key-3", `{}`)
+	panics.Store(false)
+
+	if resp, _ := send("user-1", "key-3", `{}`); resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected a retry after a panic to run again, got %d", resp.StatusCode)
+	}
+}
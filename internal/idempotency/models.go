@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Record is a stored idempotency key. Response is nil while the first
+// request with the key is still running.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Response is what the first request answered, replayed on retries.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// ErrLeaseLost is returned by Complete when the reservation expired and
+// another request has taken the key over.
+var ErrLeaseLost = errors.New("idempotency key was taken over by another request")
+
+// Repository stores idempotency keys per user. Only the hash of a key is
+// ever stored.
+type Repository interface {
+	// Reserve claims keyHash for the user under leaseId until expiresAt, a
+	// short lease that only has to outlast the request, taking over an
+	// expired record. When the key is already claimed it returns that record
+	// and false.
+	Reserve(ctx context.Context, userId, keyHash, fingerprint, leaseId string, expiresAt time.Time) (*Record, bool, error)
+	// Complete stores the response and keeps it until expiresAt, as long as
+	// leaseId still holds the key.
+	Complete(ctx context.Context, userId, keyHash, leaseId string, response *Response, expiresAt time.Time) error
+	// Release forgets a key whose request failed, so a retry runs again.
+	Release(ctx context.Context, userId, keyHash, leaseId string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyRepository is the Postgres Repository.
+type IdempotencyRepository struct {
+	db       *pgxpool.Pool
+	timeouts storage.Timeouts
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool, timeouts storage.Timeouts) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+// SQLiteIdempotencyRepository is the SQLite Repository.
+type SQLiteIdempotencyRepository struct {
+	db       *sql.DB
+	timeouts storage.Timeouts
+}
+
+func NewSQLiteIdempotencyRepository(db *sql.DB, timeouts storage.Timeouts) *SQLiteIdempotencyRepository {
+	return &SQLiteIdempotencyRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
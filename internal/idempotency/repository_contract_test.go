@@ -0,0 +1,183 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/database/dbtest"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type backend func(t *testing.T) (idempotency.Repository, func() string)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (idempotency.Repository, func() string) {
+		return idempotency.NewMemoryIdempotencyRepository(), uuid.NewString
+	})
+}
+
+func TestPostgresRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (idempotency.Repository, func() string) {
+		pool := dbtest.Pool(t)
+		return idempotency.NewIdempotencyRepository(pool, storage.DefaultTimeouts), func() string { return dbtest.CreateUser(t, pool) }
+	})
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) (idempotency.Repository, func() string) {
+		db := dbtest.SQLite(t)
+		return idempotency.NewSQLiteIdempotencyRepository(db, storage.DefaultTimeouts), func() string { return dbtest.CreateSQLiteUser(t, db) }
+	})
+}
+
+func runRepositoryContract(t *testing.T, newBackend backend) {
+	ctx := context.Background()
+	keyHash := uuid.NewString()
+	later := time.Now().Add(time.Hour)
+
+	t.Run("a key is reserved once per user", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner, other := newUser(), newUser()
+
+		if _, reserved := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later); !reserved {
+			t.Fatal("expected the first reservation to succeed")
+		}
+
+		record, reserved := reserve(t, repo, owner, keyHash, "second", uuid.NewString(), later)
+		if reserved {
+			t.Fatal("expected the key to be taken")
+		}
+		if record.Fingerprint != "first" || record.Response != nil {
+			t.Fatalf("expected the in-progress record of the first request, got %+v", record)
+		}
+
+		if _, reserved := reserve(t, repo, other, keyHash, "first", uuid.NewString(), later); !reserved {
+			t.Fatal("expected keys to be scoped to the user")
+		}
+	})
+
+	t.Run("complete stores the response", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		lease := uuid.NewString()
+		reserve(t, repo, owner, keyHash, "first", lease, later)
+
+		err := repo.Complete(ctx, owner, keyHash, lease, &idempotency.Response{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`)}, later)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		record, _ := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later)
+		if record.Response == nil || record.Response.StatusCode != 201 || string(record.Response.Body) != `{"status":201}` || record.Response.ContentType != "application/json" {
+			t.Fatalf("expected the stored response, got %+v", record.Response)
+		}
+
+		// A completed key stays until it expires.
+		if err := repo.Release(ctx, owner, keyHash, lease); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, reserved := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later); reserved {
+			t.Fatal("expected release to keep a completed key")
+		}
+	})
+
+	t.Run("complete keeps the response past the reservation lease", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		lease := uuid.NewString()
+		reserve(t, repo, owner, keyHash, "first", lease, time.Now().Add(-time.Minute))
+
+		err := repo.Complete(ctx, owner, keyHash, lease, &idempotency.Response{StatusCode: 201, Body: []byte(`{}`)}, later)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if record, reserved := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later); reserved || record.Response == nil {
+			t.Fatal("expected the completed key to outlive its lease")
+		}
+	})
+
+	t.Run("release frees an in-progress key", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+
+		lease := uuid.NewString()
+		reserve(t, repo, owner, keyHash, "first", lease, later)
+
+		if err := repo.Release(ctx, owner, keyHash, lease); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, reserved := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later); !reserved {
+			t.Fatal("expected the released key to be free")
+		}
+	})
+
+	t.Run("a lease that was taken over can't complete or release", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+		response := &idempotency.Response{StatusCode: 201, Body: []byte(`{}`)}
+
+		stale, current := uuid.NewString(), uuid.NewString()
+		reserve(t, repo, owner, keyHash, "first", stale, time.Now().Add(-time.Minute))
+		if _, reserved := reserve(t, repo, owner, keyHash, "first", current, later); !reserved {
+			t.Fatal("expected the expired lease to be taken over")
+		}
+
+		if err := repo.Complete(ctx, owner, keyHash, stale, response, later); !errors.Is(err, idempotency.ErrLeaseLost) {
+			t.Fatalf("expected ErrLeaseLost, got %v", err)
+		}
+		if err := repo.Release(ctx, owner, keyHash, stale); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record, reserved := reserve(t, repo, owner, keyHash, "first", uuid.NewString(), later); reserved || record.Response != nil {
+			t.Fatal("expected the stale lease to leave the current one in progress")
+		}
+
+		if err := repo.Complete(ctx, owner, keyHash, current, response, later); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repo.Complete(ctx, owner, keyHash, current, response, later); !errors.Is(err, idempotency.ErrLeaseLost) {
+			t.Fatalf("expected a second completion to fail with ErrLeaseLost, got %v", err)
+		}
+	})
+
+	t.Run("expired keys are taken over and purged", func(t *testing.T) {
+		repo, newUser := newBackend(t)
+		owner := newUser()
+		expired := time.Now().Add(-time.Minute)
+
+		reserve(t, repo, owner, keyHash, "first", uuid.NewString(), expired)
+
+		if _, reserved := reserve(t, repo, owner, keyHash, "second", uuid.NewString(), later); !reserved {
+			t.Fatal("expected an expired key to be taken over")
+		}
+
+		reserve(t, repo, owner, "other-"+keyHash, "first", uuid.NewString(), expired)
+
+		purged, err := repo.PurgeExpired(ctx, time.Now())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if purged != 1 {
+			t.Fatalf("expected one purged key, got %d", purged)
+		}
+	})
+}
+
+func reserve(t *testing.T, repo idempotency.Repository, userId, keyHash, fingerprint, leaseId string, expiresAt time.Time) (*idempotency.Record, bool) {
+	t.Helper()
+
+	record, reserved, err := repo.Reserve(context.Background(), userId, keyHash, fingerprint, leaseId, expiresAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return record, reserved
+}
@@ -0,0 +1,89 @@
+// Package logging builds the JSON slog handler the server logs through.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultRedactKeys are attribute keys whose values never reach the logs,
+// compared case-insensitively.
+var DefaultRedactKeys = []string{
+	"password",
+	"currentPassword",
+	"newPassword",
+	"token",
+	"accessToken",
+	"refreshToken",
+	"challengeToken",
+	"secret",
+	"code",
+	"authorization",
+	"cookie",
+	"set-cookie",
+}
+
+type Config struct {
+	Level      slog.Level
+	RedactKeys []string
+}
+
+// NewHandler returns a JSON handler that writes records at cfg.Level and
+// above, adds the request id of the record's context and redacts
+// cfg.RedactKeys, including inside groups.
+func NewHandler(w io.Writer, cfg Config) slog.Handler {
+	redact := make(map[string]struct{}, len(cfg.RedactKeys))
+	for _, key := range cfg.RedactKeys {
+		redact[strings.ToLower(key)] = struct{}{}
+	}
+
+	return &contextHandler{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: cfg.Level,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if _, ok := redact[strings.ToLower(attr.Key)]; ok {
+				return slog.String(attr.Key, redacted)
+			}
+
+			return attr
+		},
+	})}
+}
+
+type requestIdKey struct{}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the id of the request ctx belongs to, or "" outside of
+// a request.
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// contextHandler adds the request id to records logged with the slog
+// *Context functions.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestId(ctx); requestId != "" {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestHandlerRedactsKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, Config{Level: slog.LevelInfo, RedactKeys: []string{"password", "SSN"}}))
+
+	logger.InfoContext(WithRequestId(context.Background(), "req-1"), "login",
+		"Password", "hunter2",
+		slog.Group("user", "ssn", "123-45-6789", "email", "a@b.co"),
+	)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if record["Password"] != redacted {
+		t.Errorf("expected the top level key to be redacted, got %v", record["Password"])
+	}
+
+	group, _ := record["user"].(map[string]any)
+	if group["ssn"] != redacted || group["email"] != "a@b.co" {
+		t.Errorf("expected only ssn to be redacted inside the group, got %v", group)
+	}
+
+	if record["request_id"] != "req-1" {
+		t.Errorf("expected the request id of the context, got %v", record["request_id"])
+	}
+}
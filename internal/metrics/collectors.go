@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool.Stat on every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired        *prometheus.Desc
+	idle            *prometheus.Desc
+	total           *prometheus.Desc
+	max             *prometheus.Desc
+	acquires        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	waits           *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:            pool,
+		acquired:        desc("acquired_conns", "Connections currently in use."),
+		idle:            desc("idle_conns", "Connections currently idle."),
+		total:           desc("total_conns", "Connections currently open."),
+		max:             desc("max_conns", "Maximum size of the pool."),
+		acquires:        desc("acquires_total", "Successful connection acquisitions."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+		waits:           desc("empty_acquires_total", "Acquisitions that had to wait because the pool was empty."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
+
+var applicationsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "applications"),
+	"Applications that aren't deleted, by status.",
+	[]string{"status"},
+	nil,
+)
+
+const applicationsRefresh = time.Minute
+
+// applicationsCollector keeps serving the last count when one fails.
+type applicationsCollector struct {
+	counter StatusCounter
+
+	mu        sync.Mutex
+	counts    map[string]int64
+	countedAt time.Time
+}
+
+func (c *applicationsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- applicationsDesc
+}
+
+func (c *applicationsCollector) Collect(ch chan<- prometheus.Metric) {
+	for status, count := range c.load() {
+		ch <- prometheus.MustNewConstMetric(applicationsDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+func (c *applicationsCollector) load() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.countedAt) < applicationsRefresh {
+		return c.counts
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := c.counter.CountApplicationsByStatus(ctx)
+	if err != nil {
+		slog.Warn("failed to count applications for metrics", "error", err)
+		return c.counts
+	}
+
+	c.counts, c.countedAt = counts, time.Now()
+
+	return c.counts
+}
@@ -0,0 +1,216 @@
+// Package metrics exposes Prometheus metrics for the API.
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	fiberutils "github.com/gofiber/fiber/v2/utils"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "jobtracker"
+
+// Login methods and results used as label values of the logins counter.
+const (
+	LoginPassword  = "password"
+	LoginTwoFactor = "two_factor"
+	LoginOAuth     = "oauth"
+
+	LoginSuccess           = "success"
+	LoginFailure           = "failure"
+	LoginError             = "error"
+	LoginTwoFactorRequired = "two_factor_required"
+)
+
+// Config controls where /metrics is served: its own Addr, or the API behind Token.
+type Config struct {
+	Enabled bool
+	Addr    string
+	Token   string
+}
+
+type Metrics struct {
+	cfg      Config
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	logins   *prometheus.CounterVec
+}
+
+func New(cfg Config) *Metrics {
+	m := &Metrics{
+		cfg:      cfg,
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by method and result.",
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.logins,
+	)
+
+	return m
+}
+
+// Middleware records every request under its route template, not its path.
+func (m *Metrics) Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+
+	// Record the status the error handler ends up sending.
+	if err := c.Next(); err != nil {
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	// The registry keeps label values past the request's buffers.
+	method := fiberutils.CopyString(c.Method())
+	route := fiberutils.CopyString(c.Route().Path)
+	if c.Response().StatusCode() == fiber.StatusNotFound && route == "/" {
+		route = "unmatched"
+	}
+
+	status := strconv.Itoa(c.Response().StatusCode())
+
+	m.requests.WithLabelValues(method, route, status).Inc()
+	m.duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+func (m *Metrics) ObserveLogin(method, result string) {
+	m.logins.WithLabelValues(method, result).Inc()
+}
+
+// LoginResult tells refused credentials from server errors.
+func LoginResult(err error) string {
+	if err == nil {
+		return LoginSuccess
+	}
+
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) && appErr.StatusCode < http.StatusInternalServerError {
+		return LoginFailure
+	}
+
+	return LoginError
+}
+
+func (m *Metrics) RegisterPgxPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool))
+}
+
+func (m *Metrics) RegisterSQLDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "sqlite"))
+}
+
+// StatusCounter is implemented by applications.ApplicationService.
+type StatusCounter interface {
+	CountApplicationsByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// RegisterApplications exports application counts per status, refreshed once a minute.
+func (m *Metrics) RegisterApplications(counter StatusCounter) {
+	m.registry.MustRegister(&applicationsCollector{counter: counter})
+}
+
+// ServedByApp reports whether /metrics belongs on the API app.
+func (m *Metrics) ServedByApp(isDev bool) bool {
+	if !m.cfg.Enabled || m.cfg.Addr != "" {
+		return false
+	}
+
+	if !isDev && m.cfg.Token == "" {
+		slog.Warn("not serving /metrics on the API port without METRICS_TOKEN, set it or METRICS_ADDR")
+		return false
+	}
+
+	return true
+}
+
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(m.httpHandler())
+}
+
+// Listen serves the metrics on cfg.Addr, if set, until ctx is cancelled.
+func (m *Metrics) Listen(ctx context.Context) error {
+	if !m.cfg.Enabled || m.cfg.Addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.httpHandler())
+
+	server := &http.Server{
+		Addr:              m.cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("serving metrics", "addr", m.cfg.Addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Metrics) httpHandler() http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	if m.cfg.Token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(m.cfg.Token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareRecordsStatusAndRoute(t *testing.T) {
+	m := New(Config{Enabled: true})
+
+	app := fiber.New()
+	app.Use(m.Middleware)
+	app.Use(recover.New())
+	app.Get("/items/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/missing/:id", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+	app.Get("/panics", func(c *fiber.Ctx) error { panic("boom") })
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing/1", "/panics", "/nowhere"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+
+	cases := []struct {
+		route, status string
+		want          float64
+	}{
+		{"/items/:id", "200", 2},
+		{"/missing/:id", "404", 1},
+		{"/panics", "500", 1},
+		{"unmatched", "404", 1},
+	}
+
+	for _, tc := range cases {
+		if got := testutil.ToFloat64(m.requests.WithLabelValues("GET", tc.route, tc.status)); got != tc.want {
+			t.Errorf("requests{route=%q, status=%q} = %v, want %v", tc.route, tc.status, got, tc.want)
+		}
+	}
+
+	if got := testutil.CollectAndCount(m.requests); got != len(cases) {
+		t.Errorf("expected %d request series, got %d", len(cases), got)
+	}
+}
+
+func TestLoginResult(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, LoginSuccess},
+		{appError.NewBadRequestError("Invalid credentials"), LoginFailure},
+		{appError.ErrUnauthorized, LoginFailure},
+		{appError.WrapInternalServerError(errors.New("connection refused")), LoginError},
+		{errors.New("unexpected"), LoginError},
+	}
+
+	for _, tc := range cases {
+		if got := LoginResult(tc.err); got != tc.want {
+			t.Errorf("LoginResult(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
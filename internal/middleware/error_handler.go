@@ -1,39 +1,50 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ErrorHandler turns every error that reaches Fiber into a problem+json
+// response. Errors that aren't an AppError keep their cause out of the
+// body, except in development.
 func ErrorHandler(isDev bool) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
-		code := fiber.StatusInternalServerError
-		message := "Internal Server Error"
+		problem := utils.NewProblem(c, toAppError(err))
 
-		var appError *appError.AppError
-		if errors.As(err, &appError) {
-			code = appError.StatusCode
-			message = appError.Message
+		if isDev {
+			problem.Debug = err.Error()
 		}
 
-		var fiberErr *fiber.Error
-		if errors.As(err, &fiberErr) {
-			code = fiberErr.Code
-			message = fiberErr.Message
-		}
+		return utils.SendProblem(c, problem)
+	}
+}
 
-		response := fiber.Map{
-			"status":  code,
-			"message": message,
-		}
+func toAppError(err error) *appError.AppError {
+	// Repositories wrap whatever the driver returns, so a request that ran
+	// out of time would otherwise surface as a 500.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return appError.New(err, "The request took too long", fiber.StatusServiceUnavailable).WithCode(appError.CodeTimeout)
+	}
 
-		if isDev {
-			response["error"] = err.Error()
-			response["path"] = c.Path()
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		converted := appError.New(err, fiberErr.Message, fiberErr.Code)
+		if fiberErr.Code == fiber.StatusNotFound {
+			converted.Code = appError.CodeRouteNotFound
 		}
 
-		return c.Status(code).JSON(response)
+		return converted
 	}
+
+	return appError.WrapInternalServerError(err)
 }
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext gives every request a context that handlers pass down to
+// the repositories, so nothing a request starts outlives it by more than
+// timeout. Only the timeout is enforced: fasthttp doesn't tell handlers
+// when the client disconnects, so work carries on until it finishes or the
+// timeout passes.
+func RequestContext(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
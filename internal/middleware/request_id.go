@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+)
+
+const HeaderRequestId = "X-Request-ID"
+
+// maxRequestIdLength keeps a caller from stuffing the logs through the
+// header.
+const maxRequestIdLength = 128
+
+// RequestId tags every request with an id, taken from X-Request-ID when the
+// caller, e.g. a proxy, sent a usable one. The id is echoed back in the
+// header, stored in the "requestId" local for error bodies and added to the
+// user context for logging.
+func RequestId(c *fiber.Ctx) error {
+	requestId := utils.CopyString(c.Get(HeaderRequestId))
+	if !validRequestId(requestId) {
+		requestId = uuid.NewString()
+	}
+
+	c.Locals("requestId", requestId)
+	c.Set(HeaderRequestId, requestId)
+	c.SetUserContext(logging.WithRequestId(c.UserContext(), requestId))
+
+	return c.Next()
+}
+
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+
+	for _, r := range requestId {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
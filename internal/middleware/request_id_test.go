@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestRequestId(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(false)})
+	app.Use(RequestId)
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusNoContent) })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrTeapot })
+
+	send := func(path, requestId string) *http.Response {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if requestId != "" {
+			req.Header.Set(HeaderRequestId, requestId)
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return resp
+	}
+
+	if got := send("/ok", "proxy-42.a:b_c").Header.Get(HeaderRequestId); got != "proxy-42.a:b_c" {
+		t.Errorf("expected a valid id to be kept, got %q", got)
+	}
+
+	for _, invalid := range []string{"has space", "new\nline", "<script>", strings.Repeat("a", maxRequestIdLength+1)} {
+		got := send("/ok", invalid).Header.Get(HeaderRequestId)
+		if _, err := uuid.Parse(got); err != nil {
+			t.Errorf("expected %q to be replaced by a generated id, got %q", invalid, got)
+		}
+	}
+
+	resp := send("/fail", "trace-7")
+
+	var problem struct {
+		RequestId string `json:"requestId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if problem.RequestId != "trace-7" {
+		t.Errorf("expected the request id in the problem body, got %q", problem.RequestId)
+	}
+}
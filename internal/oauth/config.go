@@ -0,0 +1,106 @@
+package oauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// presets fills in the well-known settings so that only the client
+// credentials have to be configured for these providers. Names without a
+// preset are generic OIDC providers.
+var presets = map[string]ProviderConfig{
+	"google": {
+		Type:   TypeOIDC,
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"github": {
+		Type:     TypeGitHub,
+		AuthURL:  "https://github.com/login/oauth/authorize",
+		TokenURL: "https://github.com/login/oauth/access_token",
+		APIURL:   "https://api.github.com",
+		Scopes:   []string{"read:user", "user:email"},
+	},
+}
+
+// Config is the set of enabled providers. Each ProviderConfig only has to
+// name the provider and hold its credentials; whatever else is left empty
+// comes from the presets and RedirectBaseURL.
+type Config struct {
+	Providers       []ProviderConfig
+	RedirectBaseURL string
+	SuccessRedirect string
+}
+
+// withDefaults fills in the blanks of cfg from its preset.
+func withDefaults(cfg ProviderConfig, redirectBase string) ProviderConfig {
+	preset := presets[cfg.Name]
+
+	if cfg.Type == "" {
+		cfg.Type = preset.Type
+	}
+	if cfg.Type == "" {
+		cfg.Type = TypeOIDC
+	}
+
+	if cfg.Issuer == "" {
+		cfg.Issuer = preset.Issuer
+	}
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = preset.AuthURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = preset.TokenURL
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = preset.APIURL
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = preset.Scopes
+	}
+	if cfg.Type == TypeOIDC && len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = fmt.Sprintf("%s/%s/callback", strings.TrimSuffix(redirectBase, "/"), cfg.Name)
+	}
+
+	return cfg
+}
+
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("oauth provider %s: client id is required", cfg.Name)
+	}
+
+	switch cfg.Type {
+	case TypeOIDC:
+		if cfg.Issuer == "" {
+			return nil, fmt.Errorf("oauth provider %s: issuer is required", cfg.Name)
+		}
+		return newOIDCProvider(cfg), nil
+	case TypeGitHub:
+		return newGitHubProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("oauth provider %s: unknown type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// NewRegistryFromConfig builds every enabled provider. A misconfigured one
+// is an error, so that the server doesn't start without it unnoticed.
+func NewRegistryFromConfig(cfg Config) (*Registry, error) {
+	providers := make([]Provider, 0, len(cfg.Providers))
+
+	for _, providerCfg := range cfg.Providers {
+		p, err := NewProvider(withDefaults(providerCfg, cfg.RedirectBaseURL))
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, p)
+	}
+
+	return NewRegistry(cfg.SuccessRedirect, providers...), nil
+}
@@ -0,0 +1,94 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	StateCookieName = "oauth_state"
+	FlowTTL         = 10 * time.Minute
+)
+
+var ErrStateMismatch = errors.New("oauth state mismatch")
+
+// Flow is the per-login state that has to survive the round trip through the
+// provider. It is kept in a signed, short-lived cookie.
+type Flow struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type flowClaims struct {
+	Flow
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func NewFlow(provider string) (*Flow, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Flow{
+		Provider: provider,
+		State:    state,
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    nonce,
+	}, nil
+}
+
+func SignFlow(flow *Flow) (string, error) {
+	claims := &flowClaims{
+		Flow:    *flow,
+		Purpose: auth.PurposeOAuthState,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(FlowTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return auth.SignClaims(claims)
+}
+
+// ParseFlow verifies the state cookie and checks it belongs to this callback.
+func ParseFlow(tokenString, provider, state string) (*Flow, error) {
+	claims := &flowClaims{}
+	if err := auth.ParseSignedClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != auth.PurposeOAuthState || claims.Provider != provider {
+		return nil, ErrStateMismatch
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.State), []byte(state)) != 1 {
+		return nil, ErrStateMismatch
+	}
+
+	return &claims.Flow, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
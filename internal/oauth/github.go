@@ -0,0 +1,127 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// githubProvider signs in through GitHub's OAuth apps, which don't speak
+// OIDC, so the identity comes from the REST API instead of an id_token.
+type githubProvider struct {
+	cfg    ProviderConfig
+	config *oauth2.Config
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func newGitHubProvider(cfg ProviderConfig) *githubProvider {
+	return &githubProvider{
+		cfg: cfg,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+		},
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *githubProvider) AuthCodeURL(_ context.Context, flow *Flow) (string, error) {
+	return p.config.AuthCodeURL(flow.State, oauth2.S256ChallengeOption(flow.Verifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, flow *Flow) (*Identity, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+
+	client := p.config.Client(ctx, token)
+
+	var user githubUser
+	if err := p.get(ctx, client, "/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []githubEmail
+	if err := p.get(ctx, client, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider: p.cfg.Name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	if identity.Email == "" {
+		return nil, errors.New("github account has no primary email")
+	}
+
+	identity.FirstName, identity.LastName = splitName(user.Name, user.Login)
+
+	return identity, nil
+}
+
+func (p *githubProvider) get(ctx context.Context, client *http.Client, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.APIURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github %s: unexpected status %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func splitName(name, fallback string) (string, string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fallback, ""
+	}
+
+	first, last, _ := strings.Cut(name, " ")
+
+	return first, strings.TrimSpace(last)
+}
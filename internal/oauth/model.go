@@ -0,0 +1,76 @@
+package oauth
+
+import (
+	"context"
+	"sort"
+)
+
+const (
+	TypeOIDC   = "oidc"
+	TypeGitHub = "github"
+)
+
+type ProviderConfig struct {
+	Name         string
+	Type         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// Only used by non-OIDC providers, which have no discovery document.
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// Identity is what a provider tells us about the signed-in user.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, flow *Flow) (string, error)
+	Exchange(ctx context.Context, code string, flow *Flow) (*Identity, error)
+}
+
+type Registry struct {
+	providers       map[string]Provider
+	SuccessRedirect string
+}
+
+func NewRegistry(successRedirect string, providers ...Provider) *Registry {
+	registry := &Registry{
+		providers:       make(map[string]Provider, len(providers)),
+		SuccessRedirect: successRedirect,
+	}
+
+	for _, p := range providers {
+		registry.providers[p.Name()] = p
+	}
+
+	return registry
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
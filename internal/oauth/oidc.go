@@ -0,0 +1,145 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type oidcProvider struct {
+	cfg ProviderConfig
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+type oidcClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Nonce         string `json:"nonce"`
+}
+
+func newOIDCProvider(cfg ProviderConfig) *oidcProvider {
+	return &oidcProvider{cfg: cfg}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+// discover fetches the issuer's discovery document on first use, so an
+// unreachable identity provider doesn't keep the server from starting.
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.cfg.Name, err)
+	}
+
+	p.provider = provider
+
+	return provider, nil
+}
+
+func (p *oidcProvider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, flow *Flow) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth2Config(provider).AuthCodeURL(
+		flow.State,
+		oauth2.S256ChallengeOption(flow.Verifier),
+		oidc.Nonce(flow.Nonce),
+	), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, flow *Flow) (*Identity, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Nonce != flow.Nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	// Some providers only put the email in the userinfo response.
+	if claims.Email == "" {
+		info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			return nil, fmt.Errorf("userinfo: %w", err)
+		}
+
+		if err := info.Claims(&claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+	}, nil
+}
+
+// isTrue accepts both the boolean and the string form of email_verified,
+// since not every issuer follows the spec here.
+func isTrue(v any) bool {
+	switch value := v.(type) {
+	case bool:
+		return value
+	case string:
+		b, _ := strconv.ParseBool(value)
+		return b
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/oauth/oidctest"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestOIDCProviderLogin(t *testing.T) {
+	idp := oidctest.NewServer("client", "secret", oidctest.User{
+		Subject:       "user-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		GivenName:     "Jane",
+		FamilyName:    "Doe",
+	})
+	defer idp.Close()
+
+	provider, err := NewProvider(ProviderConfig{
+		Name:         "mock",
+		Type:         TypeOIDC,
+		Issuer:       idp.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/api/v1/auth/oauth/mock/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+
+	flow, err := NewFlow("mock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, flow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if location.Query().Get("state") != flow.State {
+		t.Fatalf("expected state %s, got %s", flow.State, location.Query().Get("state"))
+	}
+
+	t.Run("exchanges the code for a verified identity", func(t *testing.T) {
+		identity, err := provider.Exchange(ctx, location.Query().Get("code"), flow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if identity.Subject != "user-1" || identity.Email != "jane@example.com" || !identity.EmailVerified {
+			t.Errorf("unexpected identity: %+v", identity)
+		}
+	})
+
+	t.Run("rejects a reused code", func(t *testing.T) {
+		if _, err := provider.Exchange(ctx, location.Query().Get("code"), flow); err == nil {
+			t.Error("expected reused code to be rejected")
+		}
+	})
+}
+
+func TestFlowRoundTrip(t *testing.T) {
+	flow, err := NewFlow("google")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, err := SignFlow(flow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ParseFlow(signed, "google", flow.State); err != nil {
+		t.Errorf("expected flow to parse, got %v", err)
+	}
+
+	if _, err := ParseFlow(signed, "github", flow.State); err == nil {
+		t.Error("expected provider mismatch to be rejected")
+	}
+
+	if _, err := ParseFlow(signed, "google", "other"); err == nil {
+		t.Error("expected state mismatch to be rejected")
+	}
+}
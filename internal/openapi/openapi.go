@@ -0,0 +1,397 @@
+package openapi
+
+import (
+	"encoding/json"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Auth is what an operation needs from the caller.
+type Auth int
+
+const (
+	Public Auth = iota
+	// Session takes a session token, as bearer or cookie.
+	Session
+	// Scoped also takes personal access tokens that have Scope.
+	Scoped
+)
+
+// Operation documents one route, with Path as registered with Fiber.
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Auth    Auth
+	Scope   string
+
+	// Query and Body are zero values of the structs the handler parses.
+	Query    any
+	Body     any
+	BodyType string
+
+	Idempotent bool
+
+	// Conditional routes require If-Match, ETag routes return the value for it.
+	Conditional bool
+	ETag        bool
+
+	Status   int
+	Response Response
+}
+
+// Response describes the success body of an operation.
+type Response struct {
+	kind        responseKind
+	value       any
+	contentType string
+}
+
+type responseKind int
+
+const (
+	envelope responseKind = iota
+	plain
+	file
+	redirect
+)
+
+// Envelope is the usual utils.Response body with data in its data member.
+func Envelope(data any) Response {
+	return Response{kind: envelope, value: data}
+}
+
+// Plain is a JSON body without the envelope.
+func Plain(body any) Response {
+	return Response{kind: plain, value: body}
+}
+
+// File is a non-JSON body like an archive or a page.
+func File(contentType string) Response {
+	return Response{kind: file, contentType: contentType}
+}
+
+// Redirect answers with 302 Found.
+func Redirect() Response {
+	return Response{kind: redirect}
+}
+
+// OneOf documents a value that can take any of the given shapes.
+func OneOf(options ...any) any {
+	return oneOf(options)
+}
+
+type oneOf []any
+
+var fiberParam = regexp.MustCompile(`:(\w+)`)
+
+// PathKey turns /applications/:id into /applications/{id}.
+func PathKey(fiberPath string) string {
+	if fiberPath != "/" {
+		fiberPath = strings.TrimSuffix(fiberPath, "/")
+	}
+
+	return fiberParam.ReplaceAllString(fiberPath, "{$1}")
+}
+
+// Document is the OpenAPI 3.1 document for the given operations.
+func Document(operations []Operation) map[string]any {
+	s := newSchemas()
+	paths := make(map[string]map[string]any)
+
+	for _, op := range operations {
+		key := PathKey(op.Path)
+		if paths[key] == nil {
+			paths[key] = make(map[string]any)
+		}
+
+		paths[key][strings.ToLower(op.Method)] = operation(s, op)
+	}
+
+	s.components["Response"] = responseSchema
+	s.components["Problem"] = problemSchema
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Job Tracker API",
+			"version": "1.0.0",
+		},
+		"servers": []map[string]any{{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": s.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "A session token from login, or a personal access token where the operation names a scope.",
+				},
+				"cookieAuth": map[string]any{
+					"type":        "apiKey",
+					"in":          "cookie",
+					"name":        auth.SessionCookieName,
+					"description": "Session cookie in cookie mode. Unsafe methods also need the " + auth.CSRFHeaderName + " header.",
+				},
+			},
+			"responses": map[string]any{
+				"Problem": map[string]any{
+					"description": "RFC 7807 problem details",
+					"content": map[string]any{
+						"application/problem+json": map[string]any{
+							"schema": Schema{"$ref": "#/components/schemas/Problem"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func JSON(operations []Operation) ([]byte, error) {
+	return json.MarshalIndent(Document(operations), "", "  ")
+}
+
+func operation(s *schemas, op Operation) map[string]any {
+	doc := map[string]any{
+		"summary":     op.Summary,
+		"operationId": operationId(op),
+		"tags":        []string{op.Tag},
+	}
+
+	if params := parameters(s, op); len(params) > 0 {
+		doc["parameters"] = params
+	}
+
+	if op.Body != nil {
+		bodyType := op.BodyType
+		if bodyType == "" {
+			bodyType = "application/json"
+		}
+
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				bodyType: map[string]any{"schema": s.of(op.Body)},
+			},
+		}
+	}
+
+	switch op.Auth {
+	case Session:
+		doc["security"] = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+	case Scoped:
+		doc["security"] = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+		doc["description"] = "Personal access tokens need the " + op.Scope + " scope."
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := response(s, op.Response)
+	if op.ETag {
+		success["headers"] = map[string]any{
+			"ETag": map[string]any{"schema": Schema{"type": "string"}},
+		}
+	}
+
+	doc["responses"] = map[string]any{
+		strconv.Itoa(status): success,
+		"default":            map[string]any{"$ref": "#/components/responses/Problem"},
+	}
+
+	return doc
+}
+
+func parameters(s *schemas, op Operation) []map[string]any {
+	var params []map[string]any
+
+	for _, match := range fiberParam.FindAllStringSubmatch(op.Path, -1) {
+		schema := Schema{"type": "string"}
+		if match[1] == "id" {
+			schema["format"] = "uuid"
+		}
+
+		params = append(params, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+
+	if op.Idempotent {
+		params = append(params, map[string]any{
+			"name":        idempotency.HeaderKey,
+			"in":          "header",
+			"description": "Retries with the same key get the first response back, with the " + idempotency.HeaderReplayed + " header set. The key on a different request is refused with 422.",
+			"schema":      Schema{"type": "string", "maxLength": 255},
+		})
+	}
+
+	if op.Conditional {
+		params = append(params, map[string]any{
+			"name":        "If-Match",
+			"in":          "header",
+			"description": "The ETag of the resource as last read. When it has changed since, the request fails with 412. Without the header it fails with 428; * skips the check.",
+			"required":    true,
+			"schema":      Schema{"type": "string"},
+		})
+	}
+
+	if op.Query != nil {
+		object := s.object(reflectType(op.Query))
+		properties := object["properties"].(map[string]Schema)
+
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			// A missing parameter is the nil of a pointer field, not null.
+			schema := properties[name]
+			if typ := schemaType(schema); typ != "" {
+				schema["type"] = typ
+			}
+
+			params = append(params, map[string]any{
+				"name":   name,
+				"in":     "query",
+				"schema": schema,
+			})
+		}
+	}
+
+	return params
+}
+
+func response(s *schemas, r Response) map[string]any {
+	s.responses = true
+	defer func() { s.responses = false }()
+
+	switch r.kind {
+	case redirect:
+		return map[string]any{
+			"description": "Redirect",
+			"headers": map[string]any{
+				"Location": map[string]any{"schema": Schema{"type": "string", "format": "uri"}},
+			},
+		}
+	case file:
+		return map[string]any{
+			"description": "OK",
+			"content": map[string]any{
+				r.contentType: map[string]any{"schema": Schema{"type": "string"}},
+			},
+		}
+	case plain:
+		return jsonResponse(s.of(r.value))
+	}
+
+	body := Schema{"$ref": "#/components/schemas/Response"}
+
+	if r.value != nil {
+		data := s.of(r.value)
+
+		// utils.WithData wraps lists with their length.
+		if isList(r.value) {
+			data = Schema{
+				"type": "object",
+				"properties": map[string]Schema{
+					"data":      data,
+					"dataCount": {"type": "integer"},
+				},
+				"required": []string{"data", "dataCount"},
+			}
+		}
+
+		body = Schema{"allOf": []Schema{body, {
+			"type":       "object",
+			"properties": map[string]Schema{"data": data},
+			"required":   []string{"data"},
+		}}}
+	}
+
+	return jsonResponse(body)
+}
+
+func jsonResponse(schema Schema) map[string]any {
+	return map[string]any{
+		"description": "OK",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": schema},
+		},
+	}
+}
+
+// operationId is the method and path in camelCase, e.g. deleteApiV1ApplicationsId.
+func operationId(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String()
+}
+
+var responseSchema = Schema{
+	"type": "object",
+	"properties": map[string]Schema{
+		"status":  {"type": "integer"},
+		"message": {"type": "string"},
+	},
+	"required": []string{"status", "message"},
+}
+
+var problemSchema = Schema{
+	"type": "object",
+	"properties": map[string]Schema{
+		"type":      {"type": "string", "format": "uri"},
+		"title":     {"type": "string"},
+		"status":    {"type": "integer"},
+		"detail":    {"type": "string"},
+		"instance":  {"type": "string"},
+		"code":      {"type": "string", "description": "Stable error code, see internal/error/codes.go"},
+		"requestId": {"type": "string"},
+		"errors": {
+			"type": "array",
+			"items": Schema{
+				"type": "object",
+				"properties": map[string]Schema{
+					"field":   {"type": "string"},
+					"message": {"type": "string"},
+				},
+			},
+		},
+	},
+	"required": []string{"type", "title", "status", "code"},
+}
+
+func reflectType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func isList(v any) bool {
+	_, ok := v.(oneOf)
+	return !ok && reflectType(v).Kind() == reflect.Slice
+}
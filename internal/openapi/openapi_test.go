@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFollowsTags(t *testing.T) {
+	s := newSchemas()
+	s.of(applications.CreateApplicationDto{})
+
+	dto := s.components["CreateApplicationDto"]
+	if want := []string{"companyName", "positionTitle"}; !reflect.DeepEqual(dto["required"], want) {
+		t.Errorf("required = %v, want %v", dto["required"], want)
+	}
+
+	properties := dto["properties"].(map[string]Schema)
+	if got := properties["companyName"]; got["minLength"] != 2 || got["maxLength"] != 255 {
+		t.Errorf("companyName = %v, want minLength 2 and maxLength 255", got)
+	}
+
+	if got := properties["jobUrl"]; got["format"] != "uri" || !reflect.DeepEqual(got["type"], []string{"string", "null"}) {
+		t.Errorf("jobUrl = %v, want a nullable uri", got)
+	}
+
+	s.responses = true
+	s.of(applications.Application{})
+
+	model := s.components["Application"]
+	if required := model["required"].([]string); len(required) != len(model["properties"].(map[string]Schema)) {
+		t.Errorf("expected every field of a response model to be required, got %v", required)
+	}
+}
+
+func TestPathKey(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/me/":                        "/api/v1/me",
+		"/api/v1/applications/:id":           "/api/v1/applications/{id}",
+		"/api/v1/auth/oauth/:provider/start": "/api/v1/auth/oauth/{provider}/start",
+		"/":                                  "/",
+	}
+
+	for in, want := range cases {
+		if got := PathKey(in); got != want {
+			t.Errorf("PathKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestSwaggerUIIntegrity downloads the pinned Swagger UI assets and checks
+// them against the hashes in DocsPage. It needs the network, so it only
+// runs with SWAGGER_UI_CHECK=1.
+func TestSwaggerUIIntegrity(t *testing.T) {
+	if os.Getenv("SWAGGER_UI_CHECK") == "" {
+		t.Skip("SWAGGER_UI_CHECK is not set")
+	}
+
+	assets := map[string]string{
+		"swagger-ui.css":       swaggerUIStylesIntegrity,
+		"swagger-ui-bundle.js": swaggerUIScriptIntegrity,
+	}
+
+	for file, want := range assets {
+		resp, err := http.Get(swaggerUIBaseURL + "/" + file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		hash := sha512.New384()
+		_, err = io.Copy(hash, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := "sha384-" + base64.StdEncoding.EncodeToString(hash.Sum(nil)); got != want {
+			t.Errorf("%s@%s has integrity %s, DocsPage has %q", file, SwaggerUIVersion, got, want)
+		}
+	}
+}
+
+// webDTOs maps the hand written types in web/src/services/dto to the Go
+// types they mirror, and whether the server sends or receives them.
+var webDTOs = map[string]struct {
+	v        any
+	response bool
+}{
+	"CreateApplicationDto": {applications.CreateApplicationDto{}, false},
+	"UpdateApplicationDto": {applications.UpdateApplicationDto{}, false},
+	"Application":          {applications.Application{}, true},
+	"LoginBody":            {user.LoginUserDto{}, false},
+	"RegisterBody":         {user.RegisterUserDto{}, false},
+	"LoginResult":          {user.LoginResult{}, true},
+}
+
+// derivedDTOs are built from other types in TypeScript, like a mapped type,
+// and follow them by construction.
+var derivedDTOs = []string{"PatchApplicationDto"}
+
+var (
+	tsType  = regexp.MustCompile(`(?s)export type\s+(\w+)\s*=\s*([\w\s&]*?)\{(.*?)\n\}`)
+	tsField = regexp.MustCompile(`^\s*(\w+)(\??):`)
+)
+
+// TestWebDTOsMatchSpec catches drift between the frontend DTOs and the
+// schemas of the spec: every field has to exist on both sides, and be
+// optional in TypeScript exactly when the spec lets it be missing or null.
+func TestWebDTOsMatchSpec(t *testing.T) {
+	files, err := filepath.Glob("../../web/src/services/dto/*.ts")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no dto files found: %v", err)
+	}
+
+	fields := make(map[string]map[string]bool)
+	intersections := make(map[string][]string)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, match := range tsType.FindAllStringSubmatch(string(data), -1) {
+			name := match[1]
+			fields[name] = make(map[string]bool)
+
+			for _, base := range strings.Split(match[2], "&") {
+				if base = strings.TrimSpace(base); base != "" {
+					intersections[name] = append(intersections[name], base)
+				}
+			}
+
+			for _, line := range strings.Split(match[3], "\n") {
+				if field := tsField.FindStringSubmatch(line); field != nil {
+					fields[name][field[1]] = field[2] == "?"
+				}
+			}
+		}
+	}
+
+	for name, bases := range intersections {
+		for _, base := range bases {
+			for field, optional := range fields[base] {
+				fields[name][field] = optional
+			}
+		}
+	}
+
+	for name, optional := range fields {
+		if slices.Contains(derivedDTOs, name) {
+			continue
+		}
+
+		dto, ok := webDTOs[name]
+		if !ok {
+			t.Errorf("web type %s isn't checked against the spec, add it to webDTOs", name)
+			continue
+		}
+
+		s := newSchemas()
+		s.responses = dto.response
+		s.of(dto.v)
+
+		schema := s.components[reflect.TypeOf(dto.v).Name()]
+		properties := schema["properties"].(map[string]Schema)
+		required, _ := schema["required"].([]string)
+
+		for field, property := range properties {
+			tsOptional, ok := optional[field]
+			if !ok {
+				t.Errorf("%s.%s is in the spec but not in the web type", name, field)
+				continue
+			}
+
+			types, _ := property["type"].([]string)
+			if specOptional := !slices.Contains(required, field) || slices.Contains(types, "null"); tsOptional != specOptional {
+				t.Errorf("%s.%s is optional %t in the web type and %t in the spec", name, field, tsOptional, specOptional)
+			}
+		}
+
+		for field := range optional {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("%s.%s is in the web type but not in the spec", name, field)
+			}
+		}
+	}
+
+	for name := range webDTOs {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("web type %s no longer exists, remove it from webDTOs", name)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package openapi
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/account"
+	"hafiztri123/hv1-job-tracker/internal/applications"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/health"
+	"hafiztri123/hv1-job-tracker/internal/token"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"net/http"
+)
+
+// Operations documents every route of router.setupRoutes, as the router tests check.
+var Operations = []Operation{
+	{
+		Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "Meta",
+		Summary:  "Public keys tokens are signed with",
+		Response: Plain(auth.JWKS{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/livez", Tag: "Meta",
+		Summary: "Liveness probe",
+		Response: Plain(struct {
+			Status string `json:"status"`
+		}{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", Tag: "Meta",
+		Summary:  "Readiness probe, 503 while a dependency is down",
+		Response: Plain(health.Report{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/openapi.json", Tag: "Meta",
+		Summary:  "This document",
+		Response: Plain(Schema{"type": "object"}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/docs", Tag: "Meta",
+		Summary:  "API documentation",
+		Response: File("text/html"),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/health", Tag: "Meta",
+		Summary:  "Plain health check",
+		Response: File("text/plain"),
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/register", Tag: "Auth",
+		Summary: "Register an account",
+		Body:    user.RegisterUserDto{}, Status: http.StatusCreated,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/login", Tag: "Auth",
+		Summary:  "Log in with email and password",
+		Body:     user.LoginUserDto{},
+		Response: Envelope(user.LoginResult{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/auth/verify", Tag: "Auth",
+		Summary: "Current user", Auth: Session,
+		Response: Envelope(user.GetUserDetailResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/logout", Tag: "Auth",
+		Summary: "Revoke the current session", Auth: Session,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/auth/csrf", Tag: "Auth",
+		Summary: "Current CSRF token in cookie mode", Auth: Session,
+		Response: Envelope(auth.CSRFTokenResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/2fa/verify", Tag: "Two-factor",
+		Summary:  "Finish a login with a two-factor or recovery code",
+		Body:     user.VerifyTwoFactorDto{},
+		Response: Envelope(user.LoginResult{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/2fa/setup", Tag: "Two-factor",
+		Summary: "Start two-factor setup", Auth: Session,
+		Response: Envelope(user.TwoFactorSetupResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/2fa/confirm", Tag: "Two-factor",
+		Summary: "Enable two-factor authentication", Auth: Session,
+		Body:     user.ConfirmTwoFactorDto{},
+		Response: Envelope(user.RecoveryCodesResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/2fa/disable", Tag: "Two-factor",
+		Summary: "Disable two-factor authentication", Auth: Session,
+		Body:     user.DisableTwoFactorDto{},
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/auth/oauth/providers", Tag: "OAuth",
+		Summary:  "Configured identity providers",
+		Response: Envelope([]string{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/auth/oauth/:provider/start", Tag: "OAuth",
+		Summary:  "Redirect to the identity provider",
+		Response: Redirect(),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/auth/oauth/:provider/callback", Tag: "OAuth",
+		Summary:  "Finish the provider login and redirect back to the app",
+		Response: Redirect(),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/email/confirm", Tag: "Profile",
+		Summary:  "Confirm an email change",
+		Body:     user.ConfirmEmailChangeDto{},
+		Response: Envelope(nil),
+	},
+
+	{
+		Method: http.MethodGet, Path: "/api/v1/me", Tag: "Profile",
+		Summary: "Profile", Auth: Session,
+		Response: Envelope(user.ProfileResponse{}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/me", Tag: "Profile",
+		Summary: "Update the profile", Auth: Session,
+		Body:     user.UpdateProfileDto{},
+		Response: Envelope(user.ProfileResponse{}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/me", Tag: "Profile",
+		Summary: "Schedule the account for deletion, undone by logging in before purgeAfter", Auth: Session,
+		Body: user.DeleteAccountDto{}, Status: http.StatusAccepted,
+		Response: Envelope(account.DeletionResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/me/export", Tag: "Profile",
+		Summary: "Export all account data as a zip archive", Auth: Session,
+		Response: File("application/zip"),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/me/password", Tag: "Profile",
+		Summary: "Change or set the password", Auth: Session,
+		Body:     user.ChangePasswordDto{},
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/me/email", Tag: "Profile",
+		Summary: "Request an email change", Auth: Session,
+		Body: user.ChangeEmailDto{}, Status: http.StatusAccepted,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/me/sessions", Tag: "Sessions",
+		Summary: "Active sessions", Auth: Session,
+		Response: Envelope([]user.Session{}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/me/sessions/:id", Tag: "Sessions",
+		Summary: "Revoke a session", Auth: Session,
+		Response: Envelope(nil),
+	},
+
+	{
+		Method: http.MethodGet, Path: "/api/v1/tokens", Tag: "Tokens",
+		Summary: "Personal access tokens", Auth: Session,
+		Response: Envelope([]token.PersonalAccessToken{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/tokens", Tag: "Tokens",
+		Summary: "Create a personal access token", Auth: Session,
+		Body: token.CreateTokenDto{}, Status: http.StatusCreated,
+		Response: Envelope(token.CreatedTokenResponse{}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/tokens/:id", Tag: "Tokens",
+		Summary: "Revoke a personal access token", Auth: Session,
+		Response: Envelope(nil),
+	},
+
+	{
+		Method: http.MethodGet, Path: "/api/v1/applications", Tag: "Applications",
+		Summary: "List applications", Auth: Scoped, Scope: auth.ScopeReadApplications,
+		Query:    applications.ApplicationQueryParams{},
+		Response: Envelope([]applications.Application{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/applications", Tag: "Applications",
+		Summary: "Create an application", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Body: applications.CreateApplicationDto{}, Status: http.StatusCreated, Idempotent: true,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/applications/:id", Tag: "Applications",
+		Summary: "Get an application", Auth: Scoped, Scope: auth.ScopeReadApplications,
+		ETag:     true,
+		Response: Envelope(applications.Application{}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/applications/:id", Tag: "Applications",
+		Summary: "Delete an application", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Conditional: true,
+		Response:    Envelope(nil),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/applications/:id", Tag: "Applications",
+		Summary: "Update an application", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Body: applications.UpdateApplicationDto{}, Conditional: true, ETag: true,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/applications/:id", Tag: "Applications",
+		Summary: "Patch an application, where null clears a field", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Body: applications.UpdateApplicationDto{}, BodyType: applications.MergePatchContentType, Conditional: true, ETag: true,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/applications/options", Tag: "Applications",
+		Summary: "Values the application fields can take", Auth: Scoped, Scope: auth.ScopeReadApplications,
+		Query:    applications.ApplicationOptionQueryParams{},
+		Response: Envelope(applications.ApplicationOptions{}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/applications/batch/delete", Tag: "Applications",
+		Summary: "Delete several applications", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Body: applications.BatchDeleteDto{}, Idempotent: true,
+		Response: Envelope(nil),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/applications/batch/status", Tag: "Applications",
+		Summary: "Set the status of several applications", Auth: Scoped, Scope: auth.ScopeWriteApplications,
+		Body: applications.BatchUpdateStatusDto{}, Idempotent: true,
+		Response: Envelope(nil),
+	},
+}
@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema is a JSON Schema object, the 2020-12 dialect OpenAPI 3.1 uses.
+type Schema map[string]any
+
+// schemas turns Go types into schemas, named structs as components.
+type schemas struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+
+	// In responses every field without omitempty is required.
+	responses bool
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (s *schemas) of(v any) Schema {
+	switch v := v.(type) {
+	case Schema:
+		return v
+	case oneOf:
+		options := make([]Schema, len(v))
+		for i, option := range v {
+			options[i] = s.of(option)
+		}
+		return Schema{"oneOf": options}
+	}
+
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) Schema {
+	if t.Kind() == reflect.Pointer {
+		// JSON Schema spells nullable as a second type.
+		schema := s.schema(t.Elem())
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []string{typ, "null"}
+		}
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.PkgPath() == "github.com/google/uuid" && t.Name() == "UUID":
+		return Schema{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	}
+
+	return Schema{}
+}
+
+func (s *schemas) ref(t reflect.Type) Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			name = strings.ToUpper(path.Base(t.PkgPath())[:1]) + path.Base(t.PkgPath())[1:] + name
+		}
+
+		s.names[t] = name
+		// Claim the name before recursing, for types that refer to themselves.
+		s.components[name] = nil
+		s.components[name] = s.object(t)
+	}
+
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) object(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	var required []string
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				collect(sf.Type)
+				continue
+			}
+
+			name := jsonName(sf)
+			if name == "" {
+				continue
+			}
+
+			schema := s.schema(sf.Type)
+			if constrain(schema, sf) || s.responses && !strings.Contains(sf.Tag.Get("json"), ",omitempty") {
+				required = append(required, name)
+			}
+
+			properties[name] = schema
+		}
+	}
+	collect(t)
+
+	object := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+
+	return object
+}
+
+// jsonName is the encoding/json name of the field, "" when it is skipped.
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return sf.Name
+	}
+
+	return name
+}
+
+// constrain copies the validate tag of sf into its schema and reports whether it is required.
+func constrain(schema Schema, sf reflect.StructField) bool {
+	required := false
+	target := schema
+
+	for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+		tag, param, _ := strings.Cut(rule, "=")
+
+		switch tag {
+		case "required":
+			required = true
+		case "dive":
+			if items, ok := target["items"].(Schema); ok {
+				target = items
+			}
+		case "email":
+			target["format"] = "email"
+		case "url":
+			target["format"] = "uri"
+		case "numeric":
+			target["pattern"] = "^[0-9]+$"
+		case "oneof":
+			target["enum"] = strings.Fields(param)
+		case "min", "max", "len":
+			limit(target, tag, param)
+		}
+	}
+
+	return required
+}
+
+func limit(schema Schema, tag, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+
+	var min, max string
+	switch typ := schemaType(schema); typ {
+	case "string":
+		min, max = "minLength", "maxLength"
+	case "array":
+		min, max = "minItems", "maxItems"
+	case "integer", "number":
+		min, max = "minimum", "maximum"
+	default:
+		return
+	}
+
+	switch tag {
+	case "min":
+		schema[min] = n
+	case "max":
+		schema[max] = n
+	case "len":
+		schema[min], schema[max] = n, n
+	}
+}
+
+func schemaType(schema Schema) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []string:
+		return typ[0]
+	}
+
+	return ""
+}
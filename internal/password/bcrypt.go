@@ -0,0 +1,32 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher only verifies hashes created before the switch to Argon2id.
+// bcrypt ignores everything past 72 bytes, so it isn't used for new hashes.
+type BcryptHasher struct{}
+
+func (BcryptHasher) Hash(string) (string, error) {
+	return "", errors.New("bcrypt is only supported for verifying existing hashes")
+}
+
+func (BcryptHasher) Verify(password, encoded string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func (BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (BcryptHasher) NeedsRehash(string) bool {
+	return true
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// BreachedList holds SHA-1 hashes of common or breached passwords, bucketed
+// by their 5 character prefix like the Have I Been Pwned range API, so a
+// lookup never compares the password against more than one bucket.
+type BreachedList struct {
+	buckets map[string]map[string]struct{}
+	size    int
+}
+
+// LoadBreachedList reads one entry per line. Lines that are a 40 character
+// hex SHA-1 (optionally followed by ":<count>", the HIBP export format) are
+// used as is; anything else is treated as a plain password and hashed.
+// Empty lines and lines starting with # are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{buckets: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1(hash) {
+			list.add(strings.ToUpper(hash))
+			continue
+		}
+
+		list.add(sha1Hex(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+
+	hash := sha1Hex(password)
+	_, ok := l.buckets[hash[:5]][hash[5:]]
+
+	return ok
+}
+
+func (l *BreachedList) Len() int {
+	if l == nil {
+		return 0
+	}
+
+	return l.size
+}
+
+func (l *BreachedList) add(hash string) {
+	bucket, ok := l.buckets[hash[:5]]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.buckets[hash[:5]] = bucket
+	}
+
+	if _, ok := bucket[hash[5:]]; !ok {
+		bucket[hash[5:]] = struct{}{}
+		l.size++
+	}
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrMismatch = errors.New("password does not match")
+
+// Hasher produces and checks encoded password hashes of one algorithm.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) error
+	// Supports reports whether encoded was produced by this algorithm.
+	Supports(encoded string) bool
+	// NeedsRehash reports whether encoded uses outdated parameters.
+	NeedsRehash(encoded string) bool
+}
+
+// Passwords hashes new passwords with the preferred hasher and still
+// verifies hashes written by the legacy ones, so existing users can keep
+// logging in until their hash is upgraded.
+type Passwords struct {
+	preferred Hasher
+	legacy    []Hasher
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+func NewPasswords(preferred Hasher, legacy ...Hasher) *Passwords {
+	return &Passwords{
+		preferred: preferred,
+		legacy:    legacy,
+	}
+}
+
+// NewArgon2idPasswords hashes with Argon2id and keeps bcrypt around for
+// hashes created before the switch.
+func NewArgon2idPasswords(params Argon2Params) *Passwords {
+	return NewPasswords(NewArgon2idHasher(params), BcryptHasher{})
+}
+
+func (p *Passwords) Hash(password string) (string, error) {
+	return p.preferred.Hash(password)
+}
+
+// Verify checks the password against the encoded hash. needsRehash is true
+// when the password matched but the hash should be replaced with one from
+// the preferred hasher.
+func (p *Passwords) Verify(password, encoded string) (needsRehash bool, err error) {
+	if p.preferred.Supports(encoded) {
+		if err := p.preferred.Verify(password, encoded); err != nil {
+			return false, err
+		}
+
+		return p.preferred.NeedsRehash(encoded), nil
+	}
+
+	for _, hasher := range p.legacy {
+		if hasher.Supports(encoded) {
+			if err := hasher.Verify(password, encoded); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}
+	}
+
+	return false, ErrMismatch
+}
+
+// VerifyDummy costs as much as verifying a real hash, for logins that have
+// no hash to check, so the response time doesn't tell whether the account
+// exists.
+func (p *Passwords) VerifyDummy(password string) {
+	p.dummyOnce.Do(func() {
+		p.dummy, _ = p.preferred.Hash("not a real password")
+	})
+
+	_ = p.preferred.Verify(password, p.dummy)
+}
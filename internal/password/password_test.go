@@ -0,0 +1,75 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testParams = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordsVerify(t *testing.T) {
+	passwords := NewPasswords(NewArgon2idHasher(testParams), BcryptHasher{})
+
+	encoded, err := passwords.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stronger := testParams
+	stronger.Iterations = 2
+	outdated, err := NewPasswords(NewArgon2idHasher(stronger)).Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		password    string
+		encoded     string
+		wantErr     bool
+		needsRehash bool
+	}{
+		{"argon2id hash matches", "correct horse battery staple", encoded, false, false},
+		{"argon2id hash rejects wrong password", "wrong", encoded, true, false},
+		{"bcrypt hash matches and needs rehash", "correct horse battery staple", string(legacy), false, true},
+		{"bcrypt hash rejects wrong password", "wrong", string(legacy), true, false},
+		{"different parameters need rehash", "correct horse battery staple", outdated, false, true},
+		{"unknown format is rejected", "correct horse battery staple", "plaintext", true, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			needsRehash, err := passwords.Verify(tc.password, tc.encoded)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if needsRehash != tc.needsRehash {
+				t.Errorf("expected needsRehash %v, got %v", tc.needsRehash, needsRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyDummy(t *testing.T) {
+	passwords := NewPasswords(NewArgon2idHasher(testParams))
+
+	passwords.VerifyDummy("correct horse battery staple")
+
+	if !passwords.preferred.Supports(passwords.dummy) {
+		t.Errorf("expected the dummy to be a hash of the preferred hasher, got %q", passwords.dummy)
+	}
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"fmt"
+	"hafiztri123/hv1-job-tracker/internal/i18n"
+	"hafiztri123/hv1-job-tracker/internal/utils"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// Policy decides whether a new password is acceptable. It's applied
+// wherever a password is set, on top of the DTO validation.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	MinScore  int
+	Breached  *BreachedList
+}
+
+// PolicyConfig is the Policy as configured, with the breached list as a
+// file to load.
+type PolicyConfig struct {
+	MinLength        int
+	MaxLength        int
+	MinScore         int
+	BreachedListFile string
+}
+
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	policy := &Policy{
+		MinLength: cfg.MinLength,
+		MaxLength: cfg.MaxLength,
+		MinScore:  cfg.MinScore,
+	}
+
+	if cfg.BreachedListFile != "" {
+		list, err := LoadBreachedList(cfg.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("load breached password list: %w", err)
+		}
+
+		slog.Info("breached password list loaded", "entries", list.Len())
+		policy.Breached = list
+	}
+
+	return policy, nil
+}
+
+// Check returns one ErrorResponse per broken rule for the given field, or
+// nil when the password is acceptable. userInputs are things like the email
+// and name that make a password easy to guess for this particular user.
+func (p *Policy) Check(field, password string, userInputs ...string) []*utils.ErrorResponse {
+	var errors []*utils.ErrorResponse
+
+	reject := func(message string) {
+		errors = append(errors, &utils.ErrorResponse{Field: field, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		errors = append(errors, utils.LimitError(field, i18n.PasswordMinLength, i18n.UnitCharacters, p.MinLength))
+	}
+	if length > p.MaxLength {
+		errors = append(errors, utils.LimitError(field, i18n.PasswordMaxLength, i18n.UnitCharacters, p.MaxLength))
+	}
+
+	for _, input := range userInputs {
+		if input != "" && strings.EqualFold(password, input) {
+			reject("password must not be the same as your email or name")
+			break
+		}
+	}
+
+	if p.Breached.Contains(password) {
+		reject("password appears in a list of common or breached passwords")
+	} else if Strength(password, userInputs...) < p.MinScore {
+		reject("password is too easy to guess, use a longer passphrase or avoid common words, names and patterns")
+	}
+
+	return errors
+}
@@ -0,0 +1,61 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	list := "# common passwords\nCorrectHorse!Battery9\n" + sha1Hex("hunter2hunter2") + ":1337\n"
+	if err := os.WriteFile(path, []byte(list), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	breached, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policy := &Policy{MinLength: 8, MaxLength: 128, MinScore: ScoreSafelyUnguessable, Breached: breached}
+
+	cases := []struct {
+		name     string
+		password string
+		wantErrs int
+	}{
+		{"strong password passes", "x7#Kp9!vQ2mZ", 0},
+		{"too short and weak", "abc", 2},
+		{"plain entry in breached list", "CorrectHorse!Battery9", 1},
+		{"hashed entry in breached list", "hunter2hunter2", 1},
+		{"same as email", "someone@example.com", 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := policy.Check("Password", tc.password, "someone@example.com", "Some", "One")
+			if len(errs) != tc.wantErrs {
+				t.Errorf("expected %d errors, got %d", tc.wantErrs, len(errs))
+			}
+
+			for _, err := range errs {
+				if err.Field != "Password" {
+					t.Errorf("expected field Password, got %s", err.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyLengthMessages(t *testing.T) {
+	policy := &Policy{MinLength: 8, MaxLength: 10}
+
+	if errs := policy.Check("password", "abc"); len(errs) != 1 || errs[0].Message != "password must be at least 8 characters" {
+		t.Errorf("unexpected errors %+v", errs)
+	}
+
+	if errs := policy.Check("password", "abcdefghijk"); len(errs) != 1 || errs[0].Message != "password must be at most 10 characters" {
+		t.Errorf("unexpected errors %+v", errs)
+	}
+}
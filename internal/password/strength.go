@@ -0,0 +1,228 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score buckets match zxcvbn: 0 is trivially guessable, 4 is very unguessable.
+const (
+	ScoreTooGuessable = iota
+	ScoreVeryGuessable
+	ScoreSomewhatGuessable
+	ScoreSafelyUnguessable
+	ScoreVeryUnguessable
+)
+
+// commonWords are matched inside passwords on top of the configured breached
+// list, so "Summer2024!" isn't scored like random characters.
+var commonWords = []string{
+	"password", "passw0rd", "p@ssword", "letmein", "welcome", "admin", "login",
+	"qwerty", "azerty", "iloveyou", "monkey", "dragon", "master", "sunshine",
+	"princess", "football", "baseball", "soccer", "superman", "batman",
+	"shadow", "trustno1", "whatever", "freedom", "secret", "summer", "winter",
+	"spring", "autumn", "hello", "love", "angel", "michael", "jordan",
+	"charlie", "starwars", "pokemon", "computer", "internet", "changeme",
+	"default", "indonesia", "jakarta", "sayang", "rahasia", "bismillah",
+	"job", "tracker", "jobtracker", "user", "test", "guest", "root",
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+// Strength estimates how many guesses an attacker needs, in the spirit of
+// zxcvbn but much smaller: the password is split into dictionary words
+// (including userInputs such as the user's name and email), repeats,
+// sequences, years and keyboard walks, each costing a handful of bits, and
+// everything else costs the bits of its character class.
+func Strength(password string, userInputs ...string) int {
+	return scoreFromBits(entropyBits(password, userInputs))
+}
+
+func scoreFromBits(bits float64) int {
+	guesses := math.Pow(2, bits)
+
+	switch {
+	case guesses < 1e3:
+		return ScoreTooGuessable
+	case guesses < 1e6:
+		return ScoreVeryGuessable
+	case guesses < 1e8:
+		return ScoreSomewhatGuessable
+	case guesses < 1e10:
+		return ScoreSafelyUnguessable
+	default:
+		return ScoreVeryUnguessable
+	}
+}
+
+func entropyBits(password string, userInputs []string) float64 {
+	original := []rune(password)
+	runes := []rune(strings.ToLower(password))
+	words := dictionary(userInputs)
+	// Roughly the rank of a word in the dictionary plus a bit for l33t or
+	// capitalisation variants.
+	wordBits := math.Log2(float64(len(words))) + 1
+
+	var bits float64
+	for i := 0; i < len(runes); {
+		if n := longestWord(runes[i:], words); n > 0 {
+			bits += wordBits
+			i += n
+			continue
+		}
+
+		if n := yearLength(runes[i:]); n > 0 {
+			bits += math.Log2(yearRange)
+			i += n
+			continue
+		}
+
+		if n := patternLength(runes[i:]); n >= 3 {
+			bits += classBits(original[i]) + math.Log2(float64(n))
+			i += n
+			continue
+		}
+
+		bits += classBits(original[i])
+		i++
+	}
+
+	return bits
+}
+
+// yearRange covers 1900-2049, which people love to append to passwords.
+const yearRange = 150
+
+func yearLength(runes []rune) int {
+	if len(runes) < 4 {
+		return 0
+	}
+
+	year := string(runes[:4])
+	if year >= "1900" && year <= "2049" {
+		return 4
+	}
+
+	return 0
+}
+
+func dictionary(userInputs []string) []string {
+	words := append([]string{}, commonWords...)
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if at := strings.IndexByte(input, '@'); at >= 0 {
+			input = input[:at]
+		}
+
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len(part) >= 3 {
+				words = append(words, part)
+			}
+		}
+	}
+
+	return words
+}
+
+func longestWord(runes []rune, words []string) int {
+	candidate := unleet(string(runes))
+	longest := 0
+
+	for _, word := range words {
+		if len(word) > longest && strings.HasPrefix(candidate, word) {
+			longest = len([]rune(word))
+		}
+	}
+
+	return longest
+}
+
+func unleet(s string) string {
+	return strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t").Replace(s)
+}
+
+// patternLength returns how many runes from the start form a repeat
+// ("aaaa"), a sequence ("abcd", "4321") or a keyboard walk ("qwer").
+func patternLength(runes []rune) int {
+	if len(runes) < 2 {
+		return len(runes)
+	}
+
+	n := 1
+	for n < len(runes) && runes[n] == runes[0] {
+		n++
+	}
+	if n >= 3 {
+		return n
+	}
+
+	step := runes[1] - runes[0]
+	if step == 1 || step == -1 {
+		n = 2
+		for n < len(runes) && runes[n]-runes[n-1] == step {
+			n++
+		}
+		if n >= 3 {
+			return n
+		}
+	}
+
+	return keyboardWalk(runes)
+}
+
+func keyboardWalk(runes []rune) int {
+	longest := 0
+
+	for _, row := range keyboardRows {
+		for _, line := range []string{row, reverse(row)} {
+			start := strings.IndexRune(line, runes[0])
+			if start < 0 {
+				continue
+			}
+
+			lineRunes := []rune(line)
+			n := 0
+			for n < len(runes) && start+n < len(lineRunes) && lineRunes[start+n] == runes[n] {
+				n++
+			}
+
+			longest = max(longest, n)
+		}
+	}
+
+	return longest
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+// classBits is the brute force cost of a single character, based on how
+// many characters share its class.
+func classBits(r rune) float64 {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return math.Log2(26)
+	case r >= '0' && r <= '9':
+		return math.Log2(10)
+	case r < unicode.MaxASCII:
+		return math.Log2(33)
+	default:
+		return math.Log2(100)
+	}
+}
@@ -0,0 +1,31 @@
+package password
+
+import "testing"
+
+func TestStrength(t *testing.T) {
+	cases := []struct {
+		password string
+		inputs   []string
+		atLeast  int
+		atMost   int
+	}{
+		{"password", nil, ScoreTooGuessable, ScoreTooGuessable},
+		{"P@ssw0rd123", nil, ScoreTooGuessable, ScoreVeryGuessable},
+		{"qwertyuiop", nil, ScoreTooGuessable, ScoreVeryGuessable},
+		{"aaaaaaaaaaaa", nil, ScoreTooGuessable, ScoreVeryGuessable},
+		{"budisantoso99", []string{"budi.santoso@example.com"}, ScoreTooGuessable, ScoreSomewhatGuessable},
+		{"budisantoso99", nil, ScoreSafelyUnguessable, ScoreVeryUnguessable},
+		{"Summer2024!", nil, ScoreTooGuessable, ScoreSomewhatGuessable},
+		{"correct horse battery staple", nil, ScoreVeryUnguessable, ScoreVeryUnguessable},
+		{"x7#Kp9!vQ2mZ", nil, ScoreVeryUnguessable, ScoreVeryUnguessable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.password, func(t *testing.T) {
+			score := Strength(tc.password, tc.inputs...)
+			if score < tc.atLeast || score > tc.atMost {
+				t.Errorf("expected score between %d and %d, got %d", tc.atLeast, tc.atMost, score)
+			}
+		})
+	}
+}
@@ -3,30 +3,45 @@ package router
 import (
 	"hafiztri123/hv1-job-tracker/internal/auth"
 	"hafiztri123/hv1-job-tracker/internal/config"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
 	"hafiztri123/hv1-job-tracker/internal/handler"
-	"hafiztri123/hv1-job-tracker/internal/utils"
+	"hafiztri123/hv1-job-tracker/internal/idempotency"
+	"hafiztri123/hv1-job-tracker/internal/middleware"
+	"hafiztri123/hv1-job-tracker/internal/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
-func NewRouter(h *handler.Handler, cfg fiber.Config, isDev bool) *fiber.App {
-	app := fiber.New(cfg)
+func NewRouter(h *handler.Handler, cfg *config.Config) *fiber.App {
+	app := fiber.New(config.NewRouterConfig(cfg))
 
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${method} ${path} (${latency})\n",
-	}))
+	app.Use(middleware.RequestId)
+	app.Use(middleware.AccessLog)
+
+	// Outside of recover, so that a panic is counted as the 500 it becomes.
+	app.Use(h.Metrics.Middleware)
+
+	app.Use(recover.New(config.NewRecoverConfig(cfg.Server.Dev)))
 
-	app.Use(recover.New(config.NewRecoverConfig(isDev)))
+	app.Use(tracing.Middleware)
+	if h.Metrics.ServedByApp(cfg.Server.Dev) {
+		app.Get("/metrics", h.Metrics.Handler())
+	}
 
+	// Cookie mode needs credentialed requests, which config validation only
+	// allows together with an explicit list of origins.
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: utils.GetEnv("CORS_ORIGIN", "*"),
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowOrigins:     cfg.CORS.Origins,
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, " + auth.CSRFHeaderName + ", " + middleware.HeaderRequestId + ", " + idempotency.HeaderKey + ", " + fiber.HeaderIfMatch,
+		AllowMethods:     "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+		ExposeHeaders:    middleware.HeaderRequestId + ", " + idempotency.HeaderReplayed + ", " + fiber.HeaderETag,
+		AllowCredentials: h.AuthCookies.Enabled,
 	}))
 
+	app.Use(middleware.RequestContext(cfg.Limits.RequestTimeout))
+
 	setupRoutes(app, h)
 
 	return app
@@ -34,28 +49,65 @@ func NewRouter(h *handler.Handler, cfg fiber.Config, isDev bool) *fiber.App {
 }
 
 func setupRoutes(app *fiber.App, h *handler.Handler) {
+	app.Get("/.well-known/jwks.json", h.JWKSHandler)
+	app.Get("/livez", h.LivezHandler)
+	app.Get("/readyz", h.ReadyzHandler)
+
 	api := app.Group("/api/v1")
+	authMiddleware := auth.NewMiddleware(h.TokenService, h.UserService, h.AuthCookies)
 
 	api.Post("/auth/register", h.RegisterUserHandler)
 	api.Post("/auth/login", h.LoginUserHandler)
 	api.Get("/health", h.HealthHandler)
+	api.Get("/openapi.json", h.OpenAPIHandler)
+	api.Get("/docs", h.DocsHandler)
+
+	api.Get("/auth/verify", authMiddleware.AuthMiddleware, h.VerifyTokenHandler)
+	api.Post("/auth/logout", authMiddleware.AuthMiddleware, h.LogoutHandler)
+	api.Get("/auth/csrf", authMiddleware.AuthMiddleware, h.CSRFTokenHandler)
+
+	api.Post("/auth/2fa/verify", h.VerifyTwoFactorHandler)
+	api.Post("/auth/2fa/setup", authMiddleware.AuthMiddleware, h.SetupTwoFactorHandler)
+	api.Post("/auth/2fa/confirm", authMiddleware.AuthMiddleware, h.ConfirmTwoFactorHandler)
+	api.Post("/auth/2fa/disable", authMiddleware.AuthMiddleware, h.DisableTwoFactorHandler)
+
+	api.Get("/auth/oauth/providers", h.OAuthProvidersHandler)
+	api.Get("/auth/oauth/:provider/start", h.OAuthStartHandler)
+	api.Get("/auth/oauth/:provider/callback", h.OAuthCallbackHandler)
+
+	api.Post("/auth/email/confirm", h.ConfirmEmailChangeHandler)
+
+	me := api.Group("/me")
+	me.Use(authMiddleware.AuthMiddleware)
+	me.Get("/", h.GetProfileHandler)
+	me.Patch("/", h.UpdateProfileHandler)
+	me.Delete("/", h.DeleteAccountHandler)
+	me.Post("/export", h.ExportAccountHandler)
+	me.Post("/password", h.ChangePasswordHandler)
+	me.Post("/email", h.ChangeEmailHandler)
+	me.Get("/sessions", h.GetSessionsHandler)
+	me.Delete("/sessions/:id", h.RevokeSessionHandler)
 
-	api.Get("/auth/verify", auth.AuthMiddleware, h.VerifyTokenHandler)
-	api.Post("/auth/logout", auth.AuthMiddleware, h.LogoutHandler)
+	tokens := api.Group("/tokens")
+	tokens.Use(authMiddleware.AuthMiddleware)
+	tokens.Get("/", h.GetTokensHandler)
+	tokens.Post("/", h.CreateTokenHandler)
+	tokens.Delete("/:id", h.RevokeTokenHandler)
 
 	applications := api.Group("/applications")
-	applications.Use(auth.AuthMiddleware)
+	applications.Use(authMiddleware.ScopedAuthMiddleware(auth.ScopeReadApplications, auth.ScopeWriteApplications))
 	applications.Get("/", h.GetApplicationsHandler)
-	applications.Post("/", h.CreateApplicationHandler)
+	applications.Post("/", h.Idempotency.Handler, h.CreateApplicationHandler)
 	applications.Delete("/:id", h.DeleteApplicationHandler)
 	applications.Put("/:id", h.UpdateApplicationHandler)
+	applications.Patch("/:id", h.PatchApplicationHandler)
 	applications.Get("/options", h.GetApplicationOptionsHandler)
-	applications.Delete("/batch/delete", h.BatchDeleteApplicationHandler)
-	applications.Put("/batch/status", h.BatchUpdateStatusApplicationHandler)
+	// After /options, which would match it too.
+	applications.Get("/:id", h.GetApplicationHandler)
+	applications.Delete("/batch/delete", h.Idempotency.Handler, h.BatchDeleteApplicationHandler)
+	applications.Put("/batch/status", h.Idempotency.Handler, h.BatchUpdateStatusApplicationHandler)
 
 	app.Use(func(c *fiber.Ctx) error {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Route not found",
-		})
+		return appError.NewNotFoundErr("Route not found").WithCode(appError.CodeRouteNotFound)
 	})
 }
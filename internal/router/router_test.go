@@ -0,0 +1,44 @@
+package router
+
+import (
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/handler"
+	"hafiztri123/hv1-job-tracker/internal/openapi"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestRoutesAreDocumented keeps openapi.Operations in step with the router:
+// every route needs a spec entry and every entry a route.
+func TestRoutesAreDocumented(t *testing.T) {
+	app := fiber.New()
+	setupRoutes(app, &handler.Handler{AuthCookies: &auth.CookieConfig{}})
+
+	documented := make(map[string]bool)
+	for _, op := range openapi.Operations {
+		documented[op.Method+" "+openapi.PathKey(op.Path)] = true
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		// Fiber adds a HEAD route for every GET.
+		if route.Method == http.MethodHead {
+			continue
+		}
+
+		key := route.Method + " " + openapi.PathKey(route.Path)
+		registered[key] = true
+
+		if !documented[key] {
+			t.Errorf("route %s has no entry in openapi.Operations", key)
+		}
+	}
+
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("openapi.Operations documents %s, which isn't routed", key)
+		}
+	}
+}
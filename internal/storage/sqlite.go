@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteTimeFormat is how the SQLite backend stores timestamps. It matches
+// strftime('%Y-%m-%dT%H:%M:%fZ', 'now'), which the schema uses for defaults,
+// and has a fixed width so timestamps compare correctly as text.
+const SQLiteTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// SQLiteTime formats t for a SQLite query parameter. Passing a time.Time
+// directly would store it in the driver's format, which doesn't sort.
+func SQLiteTime(t time.Time) string {
+	return t.UTC().Format(SQLiteTimeFormat)
+}
+
+// SQLiteNullTime is SQLiteTime for optional timestamps.
+func SQLiteNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return SQLiteTime(*t)
+}
+
+// IsSQLiteUniqueViolation reports whether err comes from a unique index or
+// primary key. SQLite doesn't name the index in a structured way, so callers
+// that care check index against the message.
+func IsSQLiteUniqueViolation(err error, index string) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	if sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE && sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+		return false
+	}
+
+	return index == "" || strings.Contains(sqliteErr.Error(), index)
+}
+
+// SQLiteIn expands to the placeholders of an "in (...)" list starting at
+// parameter first, standing in for Postgres' = any($1) with an array.
+func SQLiteIn(first, n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", first+i)
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
@@ -0,0 +1,36 @@
+// Package storage holds what every repository implementation shares,
+// regardless of the database behind it.
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bound how long a single repository operation may run on top of
+// whatever deadline the caller's context already has.
+type Timeouts struct {
+	Read        time.Duration
+	Write       time.Duration
+	Transaction time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Read:        10 * time.Second,
+	Write:       10 * time.Second,
+	Transaction: 30 * time.Second,
+}
+
+func (t Timeouts) ForRead(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Read)
+}
+
+func (t Timeouts) ForWrite(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Write)
+}
+
+// ForTransaction covers multi-statement work such as transactions and batch
+// updates.
+func (t Timeouts) ForTransaction(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Transaction)
+}
@@ -0,0 +1,12 @@
+package token
+
+type CreateTokenDto struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read:applications write:applications"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"required,min=1,max=365"`
+}
+
+type CreatedTokenResponse struct {
+	Token string `json:"token"`
+	PersonalAccessToken
+}
@@ -0,0 +1,54 @@
+package token
+
+import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PersonalAccessToken struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"userId"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Email      string     `json:"-"`
+}
+
+// Repository stores personal access tokens. Only the hash of a token is
+// ever stored.
+type Repository interface {
+	InsertToken(ctx context.Context, userId, name, tokenHash string, scopes []string, expiresAt time.Time) (*PersonalAccessToken, error)
+	FindTokensByUserId(ctx context.Context, userId string) ([]PersonalAccessToken, error)
+	FindActiveTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	TouchToken(ctx context.Context, tokenId string) error
+	RevokeToken(ctx context.Context, userId, tokenId string) error
+}
+
+// TokenRepository is the Postgres Repository.
+type TokenRepository struct {
+	db       *pgxpool.Pool
+	timeouts storage.Timeouts
+}
+
+type TokenService struct {
+	repo Repository
+}
+
+func NewTokenService(repo Repository) *TokenService {
+	return &TokenService{
+		repo: repo,
+	}
+}
+
+func NewTokenRepository(db *pgxpool.Pool, timeouts storage.Timeouts) *TokenRepository {
+	return &TokenRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
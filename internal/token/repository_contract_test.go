@@ -0,0 +1,197 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/database/dbtest"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"hafiztri123/hv1-job-tracker/internal/token"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// backend is a fresh repository, a function that creates users it will
+// accept and one that runs a statement against its database directly.
+type backend struct {
+	repo    token.Repository
+	newUser func() string
+	exec    func(query string, args ...any)
+}
+
+func TestPostgresRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) backend {
+		pool := dbtest.Pool(t)
+		return backend{
+			repo:    token.NewTokenRepository(pool, storage.DefaultTimeouts),
+			newUser: func() string { return dbtest.CreateUser(t, pool) },
+			exec: func(query string, args ...any) {
+				if _, err := pool.Exec(context.Background(), query, args...); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		}
+	})
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) backend {
+		db := dbtest.SQLite(t)
+		return backend{
+			repo:    token.NewSQLiteTokenRepository(db, storage.DefaultTimeouts),
+			newUser: func() string { return dbtest.CreateSQLiteUser(t, db) },
+			exec: func(query string, args ...any) {
+				for i, arg := range args {
+					if at, ok := arg.(time.Time); ok {
+						args[i] = storage.SQLiteTime(at)
+					}
+				}
+
+				if _, err := db.Exec(query, args...); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		}
+	})
+}
+
+func runRepositoryContract(t *testing.T, newBackend func(t *testing.T) backend) {
+	ctx := context.Background()
+
+	t.Run("active tokens are found by hash", func(t *testing.T) {
+		b := newBackend(t)
+		owner := b.newUser()
+		hash := uuid.NewString()
+
+		created := insert(t, b.repo, owner, hash, time.Hour)
+
+		found, err := b.repo.FindActiveTokenByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Id != created.Id || found.UserId.String() != owner || found.Email == "" {
+			t.Errorf("expected the owner's token with their email, got %+v", found)
+		}
+		if len(found.Scopes) != 2 || found.Scopes[0] != "applications:read" || found.Scopes[1] != "applications:write" {
+			t.Errorf("expected the scopes to round-trip, got %v", found.Scopes)
+		}
+
+		assertStatus(t, errOf(b.repo.FindActiveTokenByHash(ctx, uuid.NewString())), http.StatusUnauthorized)
+	})
+
+	t.Run("revoked tokens are rejected and hidden", func(t *testing.T) {
+		b := newBackend(t)
+		owner, other := b.newUser(), b.newUser()
+		hash := uuid.NewString()
+
+		created := insert(t, b.repo, owner, hash, time.Hour)
+		id := created.Id.String()
+
+		assertStatus(t, b.repo.RevokeToken(ctx, other, id), http.StatusNotFound)
+
+		if err := b.repo.RevokeToken(ctx, owner, id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, errOf(b.repo.FindActiveTokenByHash(ctx, hash)), http.StatusUnauthorized)
+		assertStatus(t, b.repo.RevokeToken(ctx, owner, id), http.StatusNotFound)
+
+		tokens, err := b.repo.FindTokensByUserId(ctx, owner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tokens) != 0 {
+			t.Errorf("expected revoked tokens to be left out, got %d", len(tokens))
+		}
+	})
+
+	t.Run("expired tokens are rejected", func(t *testing.T) {
+		b := newBackend(t)
+		hash := uuid.NewString()
+
+		insert(t, b.repo, b.newUser(), hash, -time.Minute)
+
+		assertStatus(t, errOf(b.repo.FindActiveTokenByHash(ctx, hash)), http.StatusUnauthorized)
+	})
+
+	t.Run("tokens of deleted accounts are rejected", func(t *testing.T) {
+		b := newBackend(t)
+		owner := b.newUser()
+		hash := uuid.NewString()
+
+		insert(t, b.repo, owner, hash, time.Hour)
+		b.exec(`update users set deleted_at = $1 where id = $2`, time.Now(), owner)
+
+		assertStatus(t, errOf(b.repo.FindActiveTokenByHash(ctx, hash)), http.StatusUnauthorized)
+	})
+
+	t.Run("touching a token is throttled to once a minute", func(t *testing.T) {
+		b := newBackend(t)
+		hash := uuid.NewString()
+
+		created := insert(t, b.repo, b.newUser(), hash, time.Hour)
+		id := created.Id.String()
+
+		touch := func() *time.Time {
+			t.Helper()
+
+			if err := b.repo.TouchToken(ctx, id); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			found, err := b.repo.FindActiveTokenByHash(ctx, hash)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			return found.LastUsedAt
+		}
+
+		first := touch()
+		if first == nil {
+			t.Fatal("expected the first use to be recorded")
+		}
+
+		if again := touch(); again == nil || !again.Equal(*first) {
+			t.Errorf("expected a second use within the minute to be skipped, got %v after %v", again, first)
+		}
+
+		earlier := time.Now().Add(-2 * time.Minute)
+		b.exec(`update personal_access_tokens set last_used_at = $1 where id = $2`, earlier, id)
+
+		if later := touch(); later == nil || !later.After(earlier) {
+			t.Errorf("expected a use after the minute to be recorded, got %v", later)
+		}
+	})
+}
+
+func insert(t *testing.T, repo token.Repository, userId, hash string, expiresIn time.Duration) *token.PersonalAccessToken {
+	t.Helper()
+
+	created, err := repo.InsertToken(context.Background(), userId, "ci", hash, []string{"applications:read", "applications:write"}, time.Now().Add(expiresIn))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return created
+}
+
+func errOf[T any](_ T, err error) error {
+	return err
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected an AppError with status %d, got %v", status, err)
+	}
+
+	if appErr.StatusCode != status {
+		t.Errorf("expected status %d, got %d", status, appErr.StatusCode)
+	}
+}
@@ -0,0 +1,166 @@
+package token
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *TokenRepository) InsertToken(ctx context.Context, userId, name, tokenHash string, scopes []string, expiresAt time.Time) (*PersonalAccessToken, error) {
+	createQuery := `
+		insert into personal_access_tokens (
+			user_id,
+			name,
+			token_hash,
+			scopes,
+			expires_at
+		) values ($1, $2, $3, $4, $5)
+		returning id, user_id, name, scopes, expires_at, last_used_at, created_at
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	token := new(PersonalAccessToken)
+	err := r.db.QueryRow(ctx, createQuery, userId, name, tokenHash, scopes, expiresAt).Scan(
+		&token.Id,
+		&token.UserId,
+		&token.Name,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+func (r *TokenRepository) FindTokensByUserId(ctx context.Context, userId string) ([]PersonalAccessToken, error) {
+	fetchQuery := `
+		select id, user_id, name, scopes, expires_at, last_used_at, created_at
+		from personal_access_tokens
+		where user_id = $1 and revoked_at is null
+		order by created_at desc
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []PersonalAccessToken{}
+
+	for rows.Next() {
+		var token PersonalAccessToken
+
+		err := rows.Scan(
+			&token.Id,
+			&token.UserId,
+			&token.Name,
+			&token.Scopes,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// FindActiveTokenByHash only returns tokens that are unrevoked, unexpired and
+// belong to an account that still exists.
+func (r *TokenRepository) FindActiveTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	fetchQuery := `
+		select t.id, t.user_id, t.name, t.scopes, t.expires_at, t.last_used_at, t.created_at, u.email
+		from personal_access_tokens t
+		join users u on u.id = t.user_id
+		where t.token_hash = $1
+			and t.revoked_at is null
+			and t.expires_at > now()
+			and u.deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	token := new(PersonalAccessToken)
+	err := r.db.QueryRow(ctx, fetchQuery, tokenHash).Scan(
+		&token.Id,
+		&token.UserId,
+		&token.Name,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+		&token.Email,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appError.ErrUnauthorized
+		}
+
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// TouchToken updates last_used_at at most once a minute so that busy scripts
+// don't turn every request into a write.
+func (r *TokenRepository) TouchToken(ctx context.Context, tokenId string) error {
+	updateQuery := `
+		update personal_access_tokens
+		set last_used_at = now()
+		where id = $1 and (last_used_at is null or last_used_at < now() - interval '1 minute')
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, updateQuery, tokenId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) RevokeToken(ctx context.Context, userId, tokenId string) error {
+	updateQuery := `
+		update personal_access_tokens
+		set revoked_at = now()
+		where id = $1 and user_id = $2 and revoked_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.Exec(ctx, updateQuery, tokenId, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.NewNotFoundErr("Token not found").WithCode(appError.CodeTokenNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// CreateToken returns the plain token. Only its hash is stored, so this is
+// the only time the user gets to see it.
+func (s *TokenService) CreateToken(ctx context.Context, userId string, req *CreateTokenDto) (*CreatedTokenResponse, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	plain := auth.PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+
+	token, err := s.repo.InsertToken(ctx, userId, req.Name, hashToken(plain), scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatedTokenResponse{
+		Token:               plain,
+		PersonalAccessToken: *token,
+	}, nil
+}
+
+func (s *TokenService) GetTokens(ctx context.Context, userId string) ([]PersonalAccessToken, error) {
+	return s.repo.FindTokensByUserId(ctx, userId)
+}
+
+func (s *TokenService) RevokeToken(ctx context.Context, userId, tokenId string) error {
+	return s.repo.RevokeToken(ctx, userId, tokenId)
+}
+
+// AuthenticateToken implements auth.TokenAuthenticator.
+func (s *TokenService) AuthenticateToken(ctx context.Context, rawToken string) (*auth.Principal, error) {
+	token, err := s.repo.FindActiveTokenByHash(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchToken(ctx, token.Id.String()); err != nil {
+		slog.WarnContext(ctx, "failed to record token usage", "tokenId", token.Id, "error", err)
+	}
+
+	return &auth.Principal{
+		UserId:              token.UserId.String(),
+		Email:               token.Email,
+		Scopes:              token.Scopes,
+		PersonalAccessToken: true,
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,211 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteTokenRepository is the SQLite Repository. SQLite has no arrays, so
+// scopes are stored as a JSON array.
+type SQLiteTokenRepository struct {
+	db       *sql.DB
+	timeouts storage.Timeouts
+}
+
+func NewSQLiteTokenRepository(db *sql.DB, timeouts storage.Timeouts) *SQLiteTokenRepository {
+	return &SQLiteTokenRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+func (r *SQLiteTokenRepository) InsertToken(ctx context.Context, userId, name, tokenHash string, scopes []string, expiresAt time.Time) (*PersonalAccessToken, error) {
+	createQuery := `
+		insert into personal_access_tokens (
+			id,
+			user_id,
+			name,
+			token_hash,
+			scopes,
+			expires_at
+		) values ($1, $2, $3, $4, $5, $6)
+		returning id, user_id, name, scopes, expires_at, last_used_at, created_at
+	`
+
+	encodedScopes, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	token, err := scanToken(r.db.QueryRowContext(
+		ctx,
+		createQuery,
+		uuid.NewString(),
+		userId,
+		name,
+		tokenHash,
+		string(encodedScopes),
+		storage.SQLiteTime(expiresAt),
+	), false)
+	if err != nil {
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+func (r *SQLiteTokenRepository) FindTokensByUserId(ctx context.Context, userId string) ([]PersonalAccessToken, error) {
+	fetchQuery := `
+		select id, user_id, name, scopes, expires_at, last_used_at, created_at
+		from personal_access_tokens
+		where user_id = $1 and revoked_at is null
+		order by created_at desc
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []PersonalAccessToken{}
+
+	for rows.Next() {
+		token, err := scanToken(rows, false)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// FindActiveTokenByHash only returns tokens that are unrevoked, unexpired and
+// belong to an account that still exists.
+func (r *SQLiteTokenRepository) FindActiveTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	fetchQuery := `
+		select t.id, t.user_id, t.name, t.scopes, t.expires_at, t.last_used_at, t.created_at, u.email
+		from personal_access_tokens t
+		join users u on u.id = t.user_id
+		where t.token_hash = $1
+			and t.revoked_at is null
+			and t.expires_at > $2
+			and u.deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	token, err := scanToken(r.db.QueryRowContext(ctx, fetchQuery, tokenHash, storage.SQLiteTime(time.Now())), true)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appError.ErrUnauthorized
+		}
+
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// TouchToken updates last_used_at at most once a minute so that busy scripts
+// don't turn every request into a write.
+func (r *SQLiteTokenRepository) TouchToken(ctx context.Context, tokenId string) error {
+	updateQuery := `
+		update personal_access_tokens
+		set last_used_at = $1
+		where id = $2 and (last_used_at is null or last_used_at < $3)
+	`
+
+	now := time.Now()
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, updateQuery, storage.SQLiteTime(now), tokenId, storage.SQLiteTime(now.Add(-time.Minute)))
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteTokenRepository) RevokeToken(ctx context.Context, userId, tokenId string) error {
+	updateQuery := `
+		update personal_access_tokens
+		set revoked_at = $1
+		where id = $2 and user_id = $3 and revoked_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, updateQuery, storage.SQLiteTime(time.Now()), tokenId, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if revoked == 0 {
+		return appError.NewNotFoundErr("Token not found").WithCode(appError.CodeTokenNotFound)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanToken reads the common token columns, followed by the owner's email
+// when withEmail is set.
+func scanToken(row scanner, withEmail bool) (*PersonalAccessToken, error) {
+	token := new(PersonalAccessToken)
+	var scopes string
+
+	dest := []any{
+		&token.Id,
+		&token.UserId,
+		&token.Name,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	}
+	if withEmail {
+		dest = append(dest, &token.Email)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
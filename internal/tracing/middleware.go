@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts the server span of a request, continuing the trace of
+// an incoming traceparent header, and hands it to the handlers through the
+// user context. It has to run outside of the middleware that turns errors
+// into responses so the span sees the final status.
+func Middleware(c *fiber.Ctx) error {
+	// fasthttp normalizes header names to Traceparent, MapCarrier looks
+	// them up in lower case.
+	carrier := propagation.MapCarrier{}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		carrier[strings.ToLower(string(key))] = string(value)
+	})
+
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+	// Spans outlive the request, and Fiber reuses the buffers behind these
+	// strings once it's done.
+	method := utils.CopyString(c.Method())
+
+	ctx, span := tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(utils.CopyString(c.Path())),
+			semconv.ClientAddress(utils.CopyString(c.IP())),
+			semconv.UserAgentOriginal(utils.CopyString(c.Get(fiber.HeaderUserAgent))),
+		),
+	)
+	defer span.End()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+
+	// The route is only known once the router has matched the request.
+	route := utils.CopyString(c.Route().Path)
+	status := c.Response().StatusCode()
+
+	span.SetName(fmt.Sprintf("%s %s", method, route))
+	span.SetAttributes(
+		semconv.HTTPRoute(route),
+		semconv.HTTPResponseStatusCode(status),
+	)
+
+	if userId, ok := c.Locals("userId").(string); ok {
+		span.SetAttributes(attribute.String("enduser.id", userId))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, "")
+	}
+
+	return err
+}
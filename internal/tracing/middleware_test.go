@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareTracesRequestAndQueries(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	queries := NewQueryTracer()
+
+	app := fiber.New()
+	app.Use(Middleware)
+	app.Get("/applications/:id", func(c *fiber.Ctx) error {
+		for _, sql := range []string{"select 1", "select 2"} {
+			ctx := queries.TraceQueryStart(c.UserContext(), nil, pgx.TraceQueryStartData{SQL: sql})
+			queries.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/applications/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var request tracetest.SpanStub
+	var children []tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer {
+			request = span
+		} else {
+			children = append(children, span)
+		}
+	}
+
+	if request.Name != "GET /applications/:id" {
+		t.Fatalf("expected the route as the request span name, got %q", request.Name)
+	}
+	if got := request.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the incoming trace to be continued, got trace %s", got)
+	}
+
+	attributes := map[string]any{}
+	for _, attr := range request.Attributes {
+		attributes[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	if attributes[string(semconv.HTTPRouteKey)] != "/applications/:id" || attributes[string(semconv.HTTPResponseStatusCodeKey)] != int64(http.StatusNoContent) {
+		t.Errorf("expected the route and status attributes, got %v", attributes)
+	}
+
+	if len(children) != 2 {
+		t.Fatalf("expected a span per query, got %d", len(children))
+	}
+	for _, child := range children {
+		if child.Name != "db.query" || child.Parent.SpanID() != request.SpanContext.SpanID() {
+			t.Errorf("expected %q to be a child of the request span, got parent %s", child.Name, child.Parent.SpanID())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SoftDeleteUser marks the account as deleted and revokes every session and
+// personal access token in the same transaction, so nothing issued before
+// the deletion keeps working during the grace period.
+func (r *UserRepository) SoftDeleteUser(ctx context.Context, userId string) (time.Time, error) {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	var deletedAt time.Time
+	err = tx.QueryRow(ctx, `
+		update users set deleted_at = now()
+		where id = $1 and deleted_at is null
+		returning deleted_at
+	`, userId).Scan(&deletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, appError.ErrNotFound
+		}
+
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	_, err = tx.Exec(ctx, `update user_sessions set revoked_at = now() where user_id = $1 and revoked_at is null`, userId)
+	if err != nil {
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	_, err = tx.Exec(ctx, `update personal_access_tokens set revoked_at = now() where user_id = $1 and revoked_at is null`, userId)
+	if err != nil {
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	return deletedAt, nil
+}
+
+// FindDeletedUserByEmail returns the most recently deleted account with the
+// email address, if it was deleted at or after since.
+func (r *UserRepository) FindDeletedUserByEmail(ctx context.Context, email string, since time.Time) (*User, error) {
+	fetchQuery := `select id, email, first_name, last_name, password_hash, totp_enabled from users
+		where lower(email) = lower($1) and deleted_at >= $2
+		order by deleted_at desc
+		limit 1
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	user := new(User)
+
+	err := r.Db.QueryRow(ctx, fetchQuery, email, since).Scan(
+		&user.ID,
+		&user.Email,
+		&user.FirstName,
+		&user.LastName,
+		&user.PasswordHash,
+		&user.TOTPEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appError.ErrNotFound
+		}
+
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) FindDeletedUserByIdentity(ctx context.Context, provider, subject string, since time.Time) (string, error) {
+	fetchQuery := `
+		select i.user_id from user_identities i
+		join users u on u.id = i.user_id
+		where i.provider = $1 and i.subject = $2 and u.deleted_at >= $3
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	var userId uuid.UUID
+	err := r.Db.QueryRow(ctx, fetchQuery, provider, subject, since).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", appError.ErrNotFound
+		}
+
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return userId.String(), nil
+}
+
+// RestoreUser undoes SoftDeleteUser for an account deleted at or after since.
+// Sessions and tokens revoked by the deletion stay revoked.
+func (r *UserRepository) RestoreUser(ctx context.Context, userId string, since time.Time) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, `update users set deleted_at = null where id = $1 and deleted_at >= $2`, userId, since)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_users_email_lower" {
+			return appError.ErrDuplicateEmail
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes accounts soft-deleted before the cutoff.
+// Everything the user owns goes with them through on delete cascade.
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, `delete from users where deleted_at is not null and deleted_at < $1`, before)
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
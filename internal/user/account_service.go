@@ -0,0 +1,54 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DeleteAccount soft-deletes the account after the user confirms it. Logging
+// in again within the grace period restores it; after that
+// PurgeDeletedAccounts removes it for good.
+func (u *UserService) DeleteAccount(ctx context.Context, userId string, req *DeleteAccountDto) (time.Time, error) {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	notConfirmed := appError.New(
+		errors.New("account deletion not confirmed"),
+		"Confirm the deletion by entering your email address",
+		http.StatusBadRequest,
+	).WithCode(appError.CodeDeletionNotConfirmed)
+
+	if err := u.confirmUser(user, req.Password, req.ConfirmEmail, notConfirmed); err != nil {
+		return time.Time{}, err
+	}
+
+	return u.Repo.SoftDeleteUser(ctx, userId)
+}
+
+// confirmUser checks the password, or for an account without one, that the
+// user typed their email address, failing with notConfirmed.
+func (u *UserService) confirmUser(user *User, password, confirmEmail string, notConfirmed error) error {
+	if user.PasswordHash != "" {
+		return u.checkPassword(user, password)
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(confirmEmail), user.Email) {
+		return notConfirmed
+	}
+
+	return nil
+}
+
+func (u *UserService) PurgeDeletedAccounts(ctx context.Context, gracePeriod time.Duration) (int64, error) {
+	return u.Repo.PurgeDeletedUsers(ctx, time.Now().Add(-gracePeriod))
+}
+
+func (u *UserService) GetIdentities(ctx context.Context, userId string) ([]Identity, error) {
+	return u.Repo.FindIdentitiesByUserId(ctx, userId)
+}
@@ -4,10 +4,57 @@ type RegisterUserDto struct {
 	Email     string `json:"email" validate:"required,email"`
 	FirstName string `json:"firstName" validate:"required,min=2,max=50"`
 	LastName  string `json:"lastName" validate:"required,min=2,max=50"`
-	Password  string `json:"password" validate:"required,min=8,max=64"`
+	Password  string `json:"password" validate:"required"`
 }
 
 type LoginUserDto struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
+
+type ConfirmTwoFactorDto struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type VerifyTwoFactorDto struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recoveryCode" validate:"required_without=Code,omitempty,min=10,max=11"`
+}
+
+// DisableTwoFactorDto takes a code or a recovery code. The password can be
+// left out by accounts that don't have one.
+type DisableTwoFactorDto struct {
+	Password     string `json:"password"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code,omitempty,min=10,max=11"`
+}
+
+type UpdateProfileDto struct {
+	FirstName *string `json:"firstName" validate:"omitempty,min=2,max=50"`
+	LastName  *string `json:"lastName" validate:"omitempty,min=2,max=50"`
+}
+
+type ChangePasswordDto struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+// ChangeEmailDto is confirmed with the password, or with the current email
+// address for accounts that don't have one.
+type ChangeEmailDto struct {
+	NewEmail     string `json:"newEmail" validate:"required,email,max=255"`
+	Password     string `json:"password"`
+	ConfirmEmail string `json:"confirmEmail"`
+}
+
+type ConfirmEmailChangeDto struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// DeleteAccountDto needs the password, or the account's email address for
+// accounts that only sign in through an identity provider.
+type DeleteAccountDto struct {
+	Password     string `json:"password"`
+	ConfirmEmail string `json:"confirmEmail"`
+}
@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TouchIdentity records a login through an already linked identity and
+// returns the id of the user it belongs to.
+func (r *UserRepository) TouchIdentity(ctx context.Context, provider, subject string) (string, error) {
+	updateQuery := `
+		update user_identities i
+		set last_login_at = now()
+		from users u
+		where u.id = i.user_id and i.provider = $1 and i.subject = $2 and u.deleted_at is null
+		returning i.user_id
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	var userId uuid.UUID
+	err := r.Db.QueryRow(ctx, updateQuery, provider, subject).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", appError.ErrNotFound
+		}
+
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return userId.String(), nil
+}
+
+func (r *UserRepository) LinkIdentity(ctx context.Context, userId string, identity *ExternalIdentity) error {
+	createQuery := `insert into user_identities (
+		user_id,
+		provider,
+		subject,
+		email,
+		last_login_at
+	) values ($1, $2, $3, $4, now())`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	_, err := r.Db.Exec(ctx, createQuery, userId, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return identityError(err)
+	}
+
+	return nil
+}
+
+// CreateUserWithIdentity registers a password-less user for a first-time
+// external login. The empty password hash never matches in LoginUser.
+func (r *UserRepository) CreateUserWithIdentity(ctx context.Context, user *User, identity *ExternalIdentity) error {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	createUserQuery := `insert into users (
+		email,
+		first_name,
+		last_name,
+		password_hash
+	) values ($1, $2, $3, '') returning id`
+
+	err = tx.QueryRow(ctx, createUserQuery, user.Email, user.FirstName, user.LastName).Scan(&user.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return appError.ErrDuplicateEmail
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	createIdentityQuery := `insert into user_identities (
+		user_id,
+		provider,
+		subject,
+		email,
+		last_login_at
+	) values ($1, $2, $3, $4, now())`
+
+	_, err = tx.Exec(ctx, createIdentityQuery, user.ID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return identityError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) FindIdentitiesByUserId(ctx context.Context, userId string) ([]Identity, error) {
+	fetchQuery := `
+		select provider, subject, email, created_at, last_login_at
+		from user_identities
+		where user_id = $1
+		order by created_at
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.Db.Query(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []Identity{}
+
+	for rows.Next() {
+		var identity Identity
+
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func identityError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return appError.New(
+			err,
+			"Identity is already linked to an account",
+			http.StatusConflict,
+		).WithCode(appError.CodeIdentityLinked)
+	}
+
+	return appError.WrapInternalServerError(err)
+}
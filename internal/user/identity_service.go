@@ -0,0 +1,85 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+)
+
+// LoginWithIdentity signs in through an external identity provider. Unknown
+// identities are linked to the account with the same email address, or get
+// a new account, but only when the provider has verified that address. An
+// account deleted within the grace period is restored.
+func (u *UserService) LoginWithIdentity(ctx context.Context, identity *ExternalIdentity, client ClientInfo) (*LoginResult, error) {
+	userId, err := u.Repo.TouchIdentity(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, appError.ErrNotFound) && u.DeletionGracePeriod > 0 {
+		deletedId, deletedErr := u.Repo.FindDeletedUserByIdentity(ctx, identity.Provider, identity.Subject, u.restorableSince())
+		switch {
+		case deletedErr == nil:
+			if err := u.restoreAccount(ctx, deletedId); err != nil {
+				return nil, err
+			}
+
+			userId, err = u.Repo.TouchIdentity(ctx, identity.Provider, identity.Subject)
+		case !errors.Is(deletedErr, appError.ErrNotFound):
+			return nil, deletedErr
+		}
+	}
+
+	if err == nil {
+		user, err := u.Repo.FindUserById(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		return u.completeLogin(ctx, user, client)
+	}
+
+	if !errors.Is(err, appError.ErrNotFound) {
+		return nil, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, appError.New(
+			errors.New("identity provider did not verify the email address"),
+			"Email address is not verified by the identity provider",
+			http.StatusForbidden,
+		).WithCode(appError.CodeIdentityEmailNotVerified)
+	}
+
+	existing, err := u.Repo.FindUserByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		if err := u.Repo.LinkIdentity(ctx, existing.ID.String(), identity); err != nil {
+			return nil, err
+		}
+
+		return u.completeLogin(ctx, existing, client)
+
+	case errors.Is(err, appError.ErrNotFound):
+		user := &User{
+			Email:     identity.Email,
+			FirstName: truncate(identity.FirstName, 50),
+			LastName:  truncate(identity.LastName, 50),
+		}
+
+		if err := u.Repo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+			return nil, err
+		}
+
+		return u.completeLogin(ctx, user, client)
+
+	default:
+		return nil, err
+	}
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max])
+}
@@ -0,0 +1,763 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryUserRepository is an in-memory Repository for tests. It mirrors the
+// Postgres behaviour, including the errors it returns and the unique email
+// index that only covers users that aren't deleted.
+type MemoryUserRepository struct {
+	mu            sync.RWMutex
+	users         map[uuid.UUID]*User
+	recoveryCodes map[uuid.UUID][]memoryRecoveryCode
+	identities    []memoryIdentity
+	emailChanges  []memoryEmailChange
+	sessions      map[uuid.UUID]*memorySession
+	challenges    map[string]*memoryChallenge
+}
+
+type memoryRecoveryCode struct {
+	hash string
+	used bool
+}
+
+type memoryIdentity struct {
+	userId uuid.UUID
+	Identity
+}
+
+type memoryEmailChange struct {
+	userId    uuid.UUID
+	newEmail  string
+	tokenHash string
+	expiresAt time.Time
+	confirmed bool
+}
+
+type memoryChallenge struct {
+	userId string
+	failed bool
+	usedAt time.Time
+}
+
+type memorySession struct {
+	Session
+	revoked bool
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:         make(map[uuid.UUID]*User),
+		recoveryCodes: make(map[uuid.UUID][]memoryRecoveryCode),
+		sessions:      make(map[uuid.UUID]*memorySession),
+		challenges:    make(map[string]*memoryChallenge),
+	}
+}
+
+func (r *MemoryUserRepository) CreateUser(_ context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.insertUser(user, user.PasswordHash)
+	return err
+}
+
+func (r *MemoryUserRepository) FindUserByEmail(_ context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.DeletedAt == nil && strings.EqualFold(user.Email, email) {
+			return cloneUser(user), nil
+		}
+	}
+
+	return nil, appError.ErrNotFound
+}
+
+func (r *MemoryUserRepository) FindUserById(_ context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.activeUser(id)
+	if !ok {
+		return nil, appError.ErrNotFound
+	}
+
+	return cloneUser(user), nil
+}
+
+func (r *MemoryUserRepository) UpdateProfile(_ context.Context, userId string, body *UpdateProfileDto) error {
+	if body.FirstName == nil && body.LastName == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if !ok {
+		return appError.ErrNotFound
+	}
+
+	if body.FirstName != nil {
+		user.FirstName = *body.FirstName
+	}
+	if body.LastName != nil {
+		user.LastName = *body.LastName
+	}
+	touch(user)
+
+	return nil
+}
+
+func (r *MemoryUserRepository) UpdatePasswordHash(_ context.Context, userId, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if !ok {
+		return appError.ErrNotFound
+	}
+
+	user.PasswordHash = passwordHash
+	touch(user)
+
+	return nil
+}
+
+func (r *MemoryUserRepository) CreateEmailChangeRequest(_ context.Context, userId, newEmail, tokenHash string, expiresAt time.Time) error {
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.emailChanges = append(r.emailChanges, memoryEmailChange{
+		userId:    id,
+		newEmail:  newEmail,
+		tokenHash: tokenHash,
+		expiresAt: expiresAt,
+	})
+
+	return nil
+}
+
+func (r *MemoryUserRepository) ConfirmEmailChange(_ context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := -1
+	for i, change := range r.emailChanges {
+		if change.tokenHash == tokenHash && !change.confirmed && change.expiresAt.After(time.Now()) {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return appError.NewBadRequestError("Email confirmation link is invalid or has expired").WithCode(appError.CodeEmailConfirmationFailed)
+	}
+
+	change := r.emailChanges[index]
+
+	if user, ok := r.users[change.userId]; ok && user.DeletedAt == nil {
+		if r.emailTaken(change.newEmail, user.ID) {
+			return appError.ErrDuplicateEmail
+		}
+
+		user.Email = change.newEmail
+		touch(user)
+	}
+
+	remaining := r.emailChanges[:0]
+	for i, other := range r.emailChanges {
+		switch {
+		case i == index:
+			other.confirmed = true
+		case other.userId == change.userId && !other.confirmed:
+			continue
+		}
+
+		remaining = append(remaining, other)
+	}
+	r.emailChanges = remaining
+
+	return nil
+}
+
+func (r *MemoryUserRepository) SaveTOTPSecret(_ context.Context, userId, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if !ok || user.TOTPEnabled {
+		return appError.NewBadRequestError("Two-factor authentication is already enabled").WithCode(appError.CodeTwoFactorEnabled)
+	}
+
+	user.TOTPSecret = &secret
+	user.TOTPLastStep = nil
+	touch(user)
+
+	return nil
+}
+
+func (r *MemoryUserRepository) EnableTOTP(_ context.Context, userId string, step int64, recoveryCodeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if !ok || user.TOTPEnabled || user.TOTPSecret == nil {
+		return appError.NewBadRequestError("Two-factor authentication setup has not been started").WithCode(appError.CodeTwoFactorNotStarted)
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = &step
+	touch(user)
+
+	codes := make([]memoryRecoveryCode, 0, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes = append(codes, memoryRecoveryCode{hash: hash})
+	}
+	r.recoveryCodes[user.ID] = codes
+
+	return nil
+}
+
+func (r *MemoryUserRepository) ConsumeTOTPStep(_ context.Context, userId string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	user, ok := r.users[id]
+	if !ok || (user.TOTPLastStep != nil && *user.TOTPLastStep >= step) {
+		return appError.ErrInvalidTwoFactorCode
+	}
+
+	user.TOTPLastStep = &step
+
+	return nil
+}
+
+func (r *MemoryUserRepository) ConsumeRecoveryCode(_ context.Context, userId, codeHash string) error {
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := r.recoveryCodes[id]
+	for i := range codes {
+		if codes[i].hash == codeHash && !codes[i].used {
+			codes[i].used = true
+			return nil
+		}
+	}
+
+	return appError.ErrInvalidTwoFactorCode
+}
+
+func (r *MemoryUserRepository) DisableTOTP(_ context.Context, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if ok {
+		user.TOTPEnabled = false
+		user.TOTPSecret = nil
+		user.TOTPLastStep = nil
+		touch(user)
+	}
+
+	if id, err := uuid.Parse(userId); err == nil {
+		delete(r.recoveryCodes, id)
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) ReplaceTOTPSecret(_ context.Context, userId, oldSecret, newSecret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if user, ok := r.users[id]; ok && user.TOTPSecret != nil && *user.TOTPSecret == oldSecret {
+		user.TOTPSecret = &newSecret
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) UseTwoFactorChallenge(_ context.Context, userId, challengeId string, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, challenge := range r.challenges {
+		if challenge.userId == userId && challenge.usedAt.Before(since) {
+			delete(r.challenges, id)
+		}
+	}
+
+	if _, ok := r.challenges[challengeId]; ok {
+		return 0, appError.ErrInvalidChallenge
+	}
+
+	r.challenges[challengeId] = &memoryChallenge{userId: userId, usedAt: time.Now()}
+
+	failures := 0
+	for _, challenge := range r.challenges {
+		if challenge.userId == userId && challenge.failed {
+			failures++
+		}
+	}
+
+	return failures, nil
+}
+
+func (r *MemoryUserRepository) FailTwoFactorChallenge(_ context.Context, challengeId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if challenge, ok := r.challenges[challengeId]; ok {
+		challenge.failed = true
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) TouchIdentity(_ context.Context, provider, subject string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.identities {
+		identity := &r.identities[i]
+		if identity.Provider != provider || identity.Subject != subject {
+			continue
+		}
+
+		if user, ok := r.users[identity.userId]; !ok || user.DeletedAt != nil {
+			break
+		}
+
+		now := time.Now()
+		identity.LastLoginAt = &now
+
+		return identity.userId.String(), nil
+	}
+
+	return "", appError.ErrNotFound
+}
+
+func (r *MemoryUserRepository) LinkIdentity(_ context.Context, userId string, identity *ExternalIdentity) error {
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertIdentity(id, identity)
+}
+
+func (r *MemoryUserRepository) CreateUserWithIdentity(_ context.Context, user *User, identity *ExternalIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.identityLinked(identity) {
+		return identityConflict()
+	}
+
+	id, err := r.insertUser(user, "")
+	if err != nil {
+		return err
+	}
+
+	user.ID = id
+
+	return r.insertIdentity(id, identity)
+}
+
+func (r *MemoryUserRepository) FindIdentitiesByUserId(_ context.Context, userId string) ([]Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identities := []Identity{}
+
+	for _, identity := range r.identities {
+		if identity.userId.String() == userId {
+			identities = append(identities, identity.Identity)
+		}
+	}
+
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].CreatedAt.Before(identities[j].CreatedAt)
+	})
+
+	return identities, nil
+}
+
+func (r *MemoryUserRepository) CreateSession(_ context.Context, userId string, client ClientInfo, device string, expiresAt time.Time) (string, error) {
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	session := &memorySession{Session: Session{
+		Id:         uuid.New(),
+		UserId:     id,
+		UserAgent:  &client.UserAgent,
+		Device:     &device,
+		IP:         &client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}}
+	r.sessions[session.Id] = session
+
+	return session.Id.String(), nil
+}
+
+func (r *MemoryUserRepository) FindActiveSessionsByUserId(_ context.Context, userId string) ([]Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []Session{}
+
+	for _, session := range r.sessions {
+		if session.UserId.String() == userId && session.active() {
+			sessions = append(sessions, session.Session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *MemoryUserRepository) FindActiveSessionLastSeen(_ context.Context, sessionId, userId string) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.session(sessionId)
+	if !ok || session.UserId.String() != userId || !session.active() {
+		return time.Time{}, appError.ErrUnauthorized
+	}
+
+	return session.LastSeenAt, nil
+}
+
+func (r *MemoryUserRepository) TouchSession(_ context.Context, sessionId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.session(sessionId); ok {
+		session.LastSeenAt = time.Now()
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) RevokeSession(_ context.Context, userId, sessionId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.session(sessionId)
+	if !ok || session.UserId.String() != userId || session.revoked {
+		return appError.NewNotFoundErr("Session not found").WithCode(appError.CodeSessionNotFound)
+	}
+
+	session.revoked = true
+
+	return nil
+}
+
+func (r *MemoryUserRepository) RevokeOtherSessions(_ context.Context, userId, keepSessionId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.UserId.String() == userId && session.Id.String() != keepSessionId {
+			session.revoked = true
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) SoftDeleteUser(_ context.Context, userId string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.activeUser(userId)
+	if !ok {
+		return time.Time{}, appError.ErrNotFound
+	}
+
+	now := time.Now()
+	user.DeletedAt = &now
+	touch(user)
+
+	for _, session := range r.sessions {
+		if session.UserId == user.ID {
+			session.revoked = true
+		}
+	}
+
+	return now, nil
+}
+
+func (r *MemoryUserRepository) FindDeletedUserByEmail(_ context.Context, email string, since time.Time) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *User
+	for _, user := range r.users {
+		if !deletedSince(user, since) || !strings.EqualFold(user.Email, email) {
+			continue
+		}
+
+		if found == nil || user.DeletedAt.After(*found.DeletedAt) {
+			found = user
+		}
+	}
+
+	if found == nil {
+		return nil, appError.ErrNotFound
+	}
+
+	return cloneUser(found), nil
+}
+
+func (r *MemoryUserRepository) FindDeletedUserByIdentity(_ context.Context, provider, subject string, since time.Time) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider != provider || identity.Subject != subject {
+			continue
+		}
+
+		if user, ok := r.users[identity.userId]; ok && deletedSince(user, since) {
+			return user.ID.String(), nil
+		}
+	}
+
+	return "", appError.ErrNotFound
+}
+
+func (r *MemoryUserRepository) RestoreUser(_ context.Context, userId string, since time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return appError.ErrNotFound
+	}
+
+	user, ok := r.users[id]
+	if !ok || !deletedSince(user, since) {
+		return appError.ErrNotFound
+	}
+
+	if r.emailTaken(user.Email, user.ID) {
+		return appError.ErrDuplicateEmail
+	}
+
+	user.DeletedAt = nil
+	touch(user)
+
+	return nil
+}
+
+func (r *MemoryUserRepository) PurgeDeletedUsers(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+
+	for id, user := range r.users {
+		if user.DeletedAt == nil || !user.DeletedAt.Before(before) {
+			continue
+		}
+
+		delete(r.users, id)
+		delete(r.recoveryCodes, id)
+
+		for sessionId, session := range r.sessions {
+			if session.UserId == id {
+				delete(r.sessions, sessionId)
+			}
+		}
+
+		identities := r.identities[:0]
+		for _, identity := range r.identities {
+			if identity.userId != id {
+				identities = append(identities, identity)
+			}
+		}
+		r.identities = identities
+
+		changes := r.emailChanges[:0]
+		for _, change := range r.emailChanges {
+			if change.userId != id {
+				changes = append(changes, change)
+			}
+		}
+		r.emailChanges = changes
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+// The helpers below expect the caller to hold the lock.
+
+func (r *MemoryUserRepository) insertUser(user *User, passwordHash string) (uuid.UUID, error) {
+	if r.emailTaken(user.Email, uuid.Nil) {
+		return uuid.Nil, appError.ErrDuplicateEmail
+	}
+
+	stored := &User{
+		ID:           uuid.New(),
+		Email:        user.Email,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+	r.users[stored.ID] = stored
+
+	return stored.ID, nil
+}
+
+func (r *MemoryUserRepository) insertIdentity(userId uuid.UUID, identity *ExternalIdentity) error {
+	if r.identityLinked(identity) {
+		return identityConflict()
+	}
+
+	now := time.Now()
+	email := identity.Email
+
+	r.identities = append(r.identities, memoryIdentity{
+		userId: userId,
+		Identity: Identity{
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       &email,
+			CreatedAt:   now,
+			LastLoginAt: &now,
+		},
+	})
+
+	return nil
+}
+
+func (r *MemoryUserRepository) identityLinked(identity *ExternalIdentity) bool {
+	for _, linked := range r.identities {
+		if linked.Provider == identity.Provider && linked.Subject == identity.Subject {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *MemoryUserRepository) emailTaken(email string, except uuid.UUID) bool {
+	for _, user := range r.users {
+		if user.ID != except && user.DeletedAt == nil && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *MemoryUserRepository) activeUser(id string) (*User, bool) {
+	userId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+
+	user, ok := r.users[userId]
+	if !ok || user.DeletedAt != nil {
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (r *MemoryUserRepository) session(id string) (*memorySession, bool) {
+	sessionId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+
+	session, ok := r.sessions[sessionId]
+	return session, ok
+}
+
+func (s *memorySession) active() bool {
+	return !s.revoked && s.ExpiresAt.After(time.Now())
+}
+
+func identityConflict() error {
+	return appError.New(
+		errors.New("identity already linked"),
+		"Identity is already linked to an account",
+		http.StatusConflict,
+	).WithCode(appError.CodeIdentityLinked)
+}
+
+func deletedSince(user *User, since time.Time) bool {
+	return user.DeletedAt != nil && !user.DeletedAt.Before(since)
+}
+
+func touch(user *User) {
+	now := time.Now()
+	user.UpdatedAt = &now
+}
+
+func cloneUser(user *User) *User {
+	c := *user
+
+	if user.TOTPSecret != nil {
+		secret := *user.TOTPSecret
+		c.TOTPSecret = &secret
+	}
+	if user.TOTPLastStep != nil {
+		step := *user.TOTPLastStep
+		c.TOTPLastStep = &step
+	}
+
+	return &c
+}
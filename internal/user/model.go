@@ -1,37 +1,151 @@
 package user
 
 import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"hafiztri123/hv1-job-tracker/internal/password"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	FirstName    string    `json:"firstName"`
-	LastName     string    `json:"lastName"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    int64     `json:"createdAt"`
-	UpdatedAt    int64     `json:"updatedAt"`
-	DeletedAt    int64     `json:"deletedAt"`
+	ID           uuid.UUID  `json:"id"`
+	Email        string     `json:"email"`
+	FirstName    string     `json:"firstName"`
+	LastName     string     `json:"lastName"`
+	PasswordHash string     `json:"-"`
+	TOTPSecret   *string    `json:"-"`
+	TOTPEnabled  bool       `json:"totpEnabled"`
+	TOTPLastStep *int64     `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    *time.Time `json:"updatedAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+}
+
+// ExternalIdentity is an account at an external identity provider that can
+// be used to sign in instead of a password.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+type Identity struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       *string    `json:"email"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+}
+
+// ClientInfo describes the device a login comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type Session struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"userId"`
+	UserAgent  *string    `json:"userAgent"`
+	Device     *string    `json:"device"`
+	IP         *string    `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	Current    bool       `json:"current"`
+	RevokedAt  *time.Time `json:"-"`
 }
 
+// Repository stores users together with their sessions, identities and
+// two-factor state. Soft-deleted users are invisible to every lookup except
+// the FindDeleted ones, which only exist to restore them.
+type Repository interface {
+	CreateUser(ctx context.Context, user *User) error
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	FindUserById(ctx context.Context, id string) (*User, error)
+
+	UpdateProfile(ctx context.Context, userId string, body *UpdateProfileDto) error
+	UpdatePasswordHash(ctx context.Context, userId, passwordHash string) error
+	CreateEmailChangeRequest(ctx context.Context, userId, newEmail, tokenHash string, expiresAt time.Time) error
+	ConfirmEmailChange(ctx context.Context, tokenHash string) error
+
+	SaveTOTPSecret(ctx context.Context, userId, secret string) error
+	EnableTOTP(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error
+	ConsumeTOTPStep(ctx context.Context, userId string, step int64) error
+	ConsumeRecoveryCode(ctx context.Context, userId, codeHash string) error
+	DisableTOTP(ctx context.Context, userId string) error
+	ReplaceTOTPSecret(ctx context.Context, userId, oldSecret, newSecret string) error
+	UseTwoFactorChallenge(ctx context.Context, userId, challengeId string, since time.Time) (int, error)
+	FailTwoFactorChallenge(ctx context.Context, challengeId string) error
+
+	TouchIdentity(ctx context.Context, provider, subject string) (string, error)
+	LinkIdentity(ctx context.Context, userId string, identity *ExternalIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *User, identity *ExternalIdentity) error
+	FindIdentitiesByUserId(ctx context.Context, userId string) ([]Identity, error)
+
+	CreateSession(ctx context.Context, userId string, client ClientInfo, device string, expiresAt time.Time) (string, error)
+	FindActiveSessionsByUserId(ctx context.Context, userId string) ([]Session, error)
+	FindActiveSessionLastSeen(ctx context.Context, sessionId, userId string) (time.Time, error)
+	TouchSession(ctx context.Context, sessionId string) error
+	RevokeSession(ctx context.Context, userId, sessionId string) error
+	RevokeOtherSessions(ctx context.Context, userId, keepSessionId string) error
+
+	SoftDeleteUser(ctx context.Context, userId string) (time.Time, error)
+	FindDeletedUserByEmail(ctx context.Context, email string, since time.Time) (*User, error)
+	FindDeletedUserByIdentity(ctx context.Context, provider, subject string, since time.Time) (string, error)
+	RestoreUser(ctx context.Context, userId string, since time.Time) error
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserRepository is the Postgres Repository.
 type UserRepository struct {
-	Db *pgxpool.Pool
+	Db       *pgxpool.Pool
+	timeouts storage.Timeouts
 }
 
 type UserService struct {
-	Repo *UserRepository
+	Repo           Repository
+	Mailer         mail.Mailer
+	Passwords      *password.Passwords
+	PasswordPolicy *password.Policy
+	TwoFactor      TwoFactorConfig
+	// EmailConfirmURL is the page email change links point to.
+	EmailConfirmURL string
+	// DeletionGracePeriod is how long a deleted account is restored by
+	// logging in again.
+	DeletionGracePeriod time.Duration
+}
+
+// TwoFactorConfig is how TOTP secrets are labelled in authenticator apps
+// and sealed at rest.
+type TwoFactorConfig struct {
+	Issuer  string
+	Secrets *auth.SecretCipher
 }
 
-func NewUserService(repo *UserRepository) *UserService {
+func NewUserService(repo Repository, mailer mail.Mailer, passwords *password.Passwords, policy *password.Policy, twoFactor TwoFactorConfig, emailConfirmURL string, deletionGracePeriod time.Duration) *UserService {
 	return &UserService{
-		Repo: repo,
+		Repo:                repo,
+		Mailer:              mailer,
+		Passwords:           passwords,
+		PasswordPolicy:      policy,
+		TwoFactor:           twoFactor,
+		EmailConfirmURL:     emailConfirmURL,
+		DeletionGracePeriod: deletionGracePeriod,
 	}
 }
 
-func NewUserRepository(db *pgxpool.Pool) *UserRepository {
+func NewUserRepository(db *pgxpool.Pool, timeouts storage.Timeouts) *UserRepository {
 	return &UserRepository{
-		Db: db,
+		Db:       db,
+		timeouts: timeouts,
 	}
 }
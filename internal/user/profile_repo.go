@@ -0,0 +1,147 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, userId string, body *UpdateProfileDto) error {
+	if body.FirstName == nil && body.LastName == nil {
+		return nil
+	}
+
+	query := "update users set updated_at = now()"
+	args := []any{}
+	paramCount := 0
+
+	if body.FirstName != nil {
+		paramCount++
+		query += fmt.Sprintf(" , first_name = $%d", paramCount)
+		args = append(args, *body.FirstName)
+	}
+
+	if body.LastName != nil {
+		paramCount++
+		query += fmt.Sprintf(" , last_name = $%d", paramCount)
+		args = append(args, *body.LastName)
+	}
+
+	query += fmt.Sprintf(" where id = $%d and deleted_at is null", paramCount+1)
+	args = append(args, userId)
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, query, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userId, passwordHash string) error {
+	updateQuery := `update users set password_hash = $1 where id = $2 and deleted_at is null`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, updateQuery, passwordHash, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) CreateEmailChangeRequest(ctx context.Context, userId, newEmail, tokenHash string, expiresAt time.Time) error {
+	createQuery := `insert into email_change_requests (
+		user_id,
+		new_email,
+		token_hash,
+		expires_at
+	) values ($1, $2, $3, $4)`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.Db.Exec(ctx, createQuery, userId, newEmail, tokenHash, expiresAt); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange applies a pending email change and discards the user's
+// other pending requests. The unique email index still guards against the
+// address having been taken since the request was made.
+func (r *UserRepository) ConfirmEmailChange(ctx context.Context, tokenHash string) error {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	confirmQuery := `
+		update email_change_requests
+		set confirmed_at = now()
+		where token_hash = $1 and confirmed_at is null and expires_at > now()
+		returning user_id, new_email
+	`
+
+	var userId uuid.UUID
+	var newEmail string
+
+	err = tx.QueryRow(ctx, confirmQuery, tokenHash).Scan(&userId, &newEmail)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return appError.NewBadRequestError("Email confirmation link is invalid or has expired").WithCode(appError.CodeEmailConfirmationFailed)
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	_, err = tx.Exec(ctx, `update users set email = $1 where id = $2 and deleted_at is null`, newEmail, userId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return appError.ErrDuplicateEmail
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	_, err = tx.Exec(ctx, `delete from email_change_requests where user_id = $1 and confirmed_at is null`, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+func (u *UserService) GetProfile(ctx context.Context, userId string) (*ProfileResponse, error) {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfileResponse{
+		ID:          user.ID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		TOTPEnabled: user.TOTPEnabled,
+		HasPassword: user.PasswordHash != "",
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}, nil
+}
+
+func (u *UserService) UpdateProfile(ctx context.Context, userId string, req *UpdateProfileDto) (*ProfileResponse, error) {
+	if err := u.Repo.UpdateProfile(ctx, userId, req); err != nil {
+		return nil, err
+	}
+
+	return u.GetProfile(ctx, userId)
+}
+
+// ChangePassword requires the current password, except for accounts created
+// through an identity provider that never had one. Every other session is
+// signed out afterwards.
+func (u *UserService) ChangePassword(ctx context.Context, userId, sessionId string, req *ChangePasswordDto) error {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordHash != "" {
+		if err := u.checkPassword(user, req.CurrentPassword); err != nil {
+			return err
+		}
+	}
+
+	if errors := u.PasswordPolicy.Check("newPassword", req.NewPassword, user.Email, user.FirstName, user.LastName); errors != nil {
+		return appError.NewValidationError(errors)
+	}
+
+	hashedPassword, err := u.hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Repo.UpdatePasswordHash(ctx, userId, hashedPassword); err != nil {
+		return err
+	}
+
+	return u.Repo.RevokeOtherSessions(ctx, userId, sessionId)
+}
+
+// RequestEmailChange sends a confirmation link to the new address. The
+// account keeps its current email until the link is used. Like
+// DeleteAccount, an account without a password confirms with its current
+// email address instead.
+func (u *UserService) RequestEmailChange(ctx context.Context, userId string, req *ChangeEmailDto) error {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	notConfirmed := appError.New(
+		errors.New("email change not confirmed"),
+		"Confirm the change by entering your current email address",
+		http.StatusBadRequest,
+	).WithCode(appError.CodeEmailChangeNotConfirmed)
+
+	if err := u.confirmUser(user, req.Password, req.ConfirmEmail, notConfirmed); err != nil {
+		return err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if err := u.Repo.CreateEmailChangeRequest(ctx, userId, newEmail, hashToken(token), time.Now().Add(emailChangeTTL)); err != nil {
+		return err
+	}
+
+	return u.Mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: "Use the link below to confirm your new email address. It expires in 24 hours.\n\n" +
+			u.EmailConfirmURL + "?token=" + token + "\n\n" +
+			"If you didn't request this change you can ignore this email.",
+	})
+}
+
+func (u *UserService) ConfirmEmailChange(ctx context.Context, req *ConfirmEmailChangeDto) error {
+	return u.Repo.ConfirmEmailChange(ctx, hashToken(req.Token))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,198 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	messages []mail.Message
+}
+
+func (m *sentMail) Send(_ context.Context, msg mail.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// confirmToken takes the token out of the last email change link.
+func (m *sentMail) confirmToken(t *testing.T) string {
+	t.Helper()
+
+	if len(m.messages) == 0 {
+		t.Fatal("expected a confirmation email")
+	}
+
+	_, rest, ok := strings.Cut(m.messages[len(m.messages)-1].Body, "?token=")
+	if !ok {
+		t.Fatalf("expected a confirmation link, got %q", m.messages[len(m.messages)-1].Body)
+	}
+
+	token, _, _ := strings.Cut(rest, "\n")
+	return token
+}
+
+func oauthUser(t *testing.T, service *user.UserService) *user.User {
+	t.Helper()
+
+	ctx := context.Background()
+	identity := &user.ExternalIdentity{Provider: "github", Subject: uniqueEmail(), Email: uniqueEmail(), EmailVerified: true}
+	if _, err := service.LoginWithIdentity(ctx, identity, user.ClientInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created, err := service.Repo.FindUserByEmail(ctx, identity.Email)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return created
+}
+
+func TestProfile(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, time.Hour)
+	id := registerUser(t, service, uniqueEmail()).ID.String()
+
+	firstName := "Changed"
+	profile, err := service.UpdateProfile(ctx, id, &user.UpdateProfileDto{FirstName: &firstName})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.FirstName != "Changed" || profile.LastName != "Lee" || !profile.HasPassword || profile.UpdatedAt == nil {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	oauthProfile, err := service.GetProfile(ctx, oauthUser(t, service).ID.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if oauthProfile.HasPassword {
+		t.Error("expected an account created through an identity provider to have no password")
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	newPassword := "another long passphrase 42"
+
+	t.Run("with a password", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		email := uniqueEmail()
+		id := registerUser(t, service, email).ID.String()
+
+		current := createSession(t, repo, id, user.ClientInfo{}, time.Now().Add(time.Hour))
+		createSession(t, repo, id, user.ClientInfo{}, time.Now().Add(time.Hour))
+
+		err := service.ChangePassword(ctx, id, current, &user.ChangePasswordDto{CurrentPassword: "wrong password", NewPassword: newPassword})
+		assertStatus(t, err, http.StatusBadRequest)
+
+		if err := service.ChangePassword(ctx, id, current, &user.ChangePasswordDto{CurrentPassword: testPassword, NewPassword: newPassword}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sessions, err := repo.FindActiveSessionsByUserId(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].Id.String() != current {
+			t.Errorf("expected only the current session to stay, got %+v", sessions)
+		}
+
+		assertStatus(t, errOf(service.LoginUser(ctx, &user.LoginUserDto{Email: email, Password: testPassword}, user.ClientInfo{})), http.StatusBadRequest)
+		if _, err := service.LoginUser(ctx, &user.LoginUserDto{Email: email, Password: newPassword}, user.ClientInfo{}); err != nil {
+			t.Errorf("expected the new password to work, got %v", err)
+		}
+	})
+
+	t.Run("without a password", func(t *testing.T) {
+		service, _ := newService(t, time.Hour)
+		id := oauthUser(t, service).ID.String()
+
+		if err := service.ChangePassword(ctx, id, "", &user.ChangePasswordDto{NewPassword: newPassword}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		profile, err := service.GetProfile(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !profile.HasPassword {
+			t.Error("expected the password to be set")
+		}
+	})
+
+	t.Run("the policy applies", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		id := registerUser(t, service, uniqueEmail()).ID.String()
+		current := createSession(t, repo, id, user.ClientInfo{}, time.Now().Add(time.Hour))
+
+		err := service.ChangePassword(ctx, id, current, &user.ChangePasswordDto{CurrentPassword: testPassword, NewPassword: "short"})
+		assertStatus(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("with a password", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		mailer := &sentMail{}
+		service.Mailer = mailer
+		id := registerUser(t, service, uniqueEmail()).ID.String()
+		newEmail := uniqueEmail()
+
+		err := service.RequestEmailChange(ctx, id, &user.ChangeEmailDto{NewEmail: newEmail, Password: "wrong password"})
+		assertStatus(t, err, http.StatusBadRequest)
+		if len(mailer.messages) != 0 {
+			t.Fatal("expected no email for a wrong password")
+		}
+
+		if err := service.RequestEmailChange(ctx, id, &user.ChangeEmailDto{NewEmail: newEmail, Password: testPassword}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mailer.messages[0].To != newEmail {
+			t.Errorf("expected the link to go to the new address, got %s", mailer.messages[0].To)
+		}
+
+		if err := service.ConfirmEmailChange(ctx, &user.ConfirmEmailChangeDto{Token: mailer.confirmToken(t)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		changed, err := repo.FindUserById(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changed.Email != newEmail {
+			t.Errorf("expected email %s, got %s", newEmail, changed.Email)
+		}
+	})
+
+	t.Run("without a password", func(t *testing.T) {
+		service, _ := newService(t, time.Hour)
+		mailer := &sentMail{}
+		service.Mailer = mailer
+		created := oauthUser(t, service)
+		id := created.ID.String()
+
+		err := service.RequestEmailChange(ctx, id, &user.ChangeEmailDto{NewEmail: uniqueEmail(), ConfirmEmail: "someone@example.com"})
+		var appErr *appError.AppError
+		if !errors.As(err, &appErr) || appErr.Code != appError.CodeEmailChangeNotConfirmed {
+			t.Fatalf("expected %s, got %v", appError.CodeEmailChangeNotConfirmed, err)
+		}
+
+		err = service.RequestEmailChange(ctx, id, &user.ChangeEmailDto{NewEmail: uniqueEmail(), ConfirmEmail: " " + strings.ToUpper(created.Email) + " "})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mailer.messages) != 1 {
+			t.Fatalf("expected one confirmation email, got %d", len(mailer.messages))
+		}
+	})
+}
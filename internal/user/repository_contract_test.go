@@ -0,0 +1,399 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/database/dbtest"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) user.Repository {
+		return user.NewMemoryUserRepository()
+	})
+}
+
+func TestPostgresRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) user.Repository {
+		return user.NewUserRepository(dbtest.Pool(t), storage.DefaultTimeouts)
+	})
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	runRepositoryContract(t, func(t *testing.T) user.Repository {
+		return user.NewSQLiteUserRepository(dbtest.SQLite(t), storage.DefaultTimeouts)
+	})
+}
+
+func runRepositoryContract(t *testing.T, newRepo func(t *testing.T) user.Repository) {
+	ctx := context.Background()
+
+	t.Run("emails are unique and case-insensitive among active users", func(t *testing.T) {
+		repo := newRepo(t)
+		email := uniqueEmail()
+
+		created := createUser(t, repo, email)
+
+		found, err := repo.FindUserByEmail(ctx, strings.ToUpper(email))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.ID != created.ID {
+			t.Errorf("expected user %s, got %s", created.ID, found.ID)
+		}
+
+		err = repo.CreateUser(ctx, &user.User{Email: strings.ToUpper(email), FirstName: "Dup", LastName: "User"})
+		assertStatus(t, err, http.StatusConflict)
+
+		if _, err := repo.SoftDeleteUser(ctx, created.ID.String()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, errOf(repo.FindUserByEmail(ctx, email)), http.StatusNotFound)
+		assertStatus(t, errOf(repo.FindUserById(ctx, created.ID.String())), http.StatusNotFound)
+		assertStatus(t, errOf(repo.SoftDeleteUser(ctx, created.ID.String())), http.StatusNotFound)
+
+		createUser(t, repo, email)
+	})
+
+	t.Run("unknown users are not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		assertStatus(t, errOf(repo.FindUserById(ctx, uuid.NewString())), http.StatusNotFound)
+		assertStatus(t, errOf(repo.FindUserByEmail(ctx, uniqueEmail())), http.StatusNotFound)
+		assertStatus(t, repo.UpdatePasswordHash(ctx, uuid.NewString(), "hash"), http.StatusNotFound)
+	})
+
+	t.Run("profile and password updates", func(t *testing.T) {
+		repo := newRepo(t)
+		u := createUser(t, repo, uniqueEmail())
+		id := u.ID.String()
+
+		firstName := "Changed"
+		if err := repo.UpdateProfile(ctx, id, &user.UpdateProfileDto{FirstName: &firstName}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repo.UpdatePasswordHash(ctx, id, "new-hash"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repo.FindUserById(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.FirstName != "Changed" || found.LastName != "User" || found.PasswordHash != "new-hash" || found.UpdatedAt == nil {
+			t.Errorf("unexpected user after update: %+v", found)
+		}
+	})
+
+	t.Run("email change is confirmed once", func(t *testing.T) {
+		repo := newRepo(t)
+		u := createUser(t, repo, uniqueEmail())
+		newEmail := uniqueEmail()
+		token := uuid.NewString()
+
+		if err := repo.CreateEmailChangeRequest(ctx, u.ID.String(), newEmail, token, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repo.ConfirmEmailChange(ctx, token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found, err := repo.FindUserById(ctx, u.ID.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.Email != newEmail {
+			t.Errorf("expected email %s, got %s", newEmail, found.Email)
+		}
+
+		assertStatus(t, repo.ConfirmEmailChange(ctx, token), http.StatusBadRequest)
+
+		expired := uuid.NewString()
+		if err := repo.CreateEmailChangeRequest(ctx, u.ID.String(), uniqueEmail(), expired, time.Now().Add(-time.Minute)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, repo.ConfirmEmailChange(ctx, expired), http.StatusBadRequest)
+	})
+
+	t.Run("two-factor steps and recovery codes are single use", func(t *testing.T) {
+		repo := newRepo(t)
+		id := createUser(t, repo, uniqueEmail()).ID.String()
+
+		assertStatus(t, repo.EnableTOTP(ctx, id, 1, nil), http.StatusBadRequest)
+
+		if err := repo.SaveTOTPSecret(ctx, id, "SECRET"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repo.EnableTOTP(ctx, id, 10, []string{"code-a", "code-b"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, repo.SaveTOTPSecret(ctx, id, "OTHER"), http.StatusBadRequest)
+
+		assertStatus(t, repo.ConsumeTOTPStep(ctx, id, 10), http.StatusBadRequest)
+		if err := repo.ConsumeTOTPStep(ctx, id, 11); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := repo.ConsumeRecoveryCode(ctx, id, "code-a"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, repo.ConsumeRecoveryCode(ctx, id, "code-a"), http.StatusBadRequest)
+
+		if err := repo.DisableTOTP(ctx, id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, repo.ConsumeRecoveryCode(ctx, id, "code-b"), http.StatusBadRequest)
+
+		found, err := repo.FindUserById(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found.TOTPEnabled || found.TOTPSecret != nil {
+			t.Errorf("expected two-factor to be off, got %+v", found)
+		}
+	})
+
+	t.Run("two-factor challenges are single use and count failures", func(t *testing.T) {
+		repo := newRepo(t)
+		id := createUser(t, repo, uniqueEmail()).ID.String()
+		since := time.Now().Add(-time.Minute)
+
+		first := uuid.NewString()
+		failures, err := repo.UseTwoFactorChallenge(ctx, id, first, since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if failures != 0 {
+			t.Errorf("expected no failures, got %d", failures)
+		}
+
+		assertStatus(t, errOf(repo.UseTwoFactorChallenge(ctx, id, first, since)), http.StatusUnauthorized)
+
+		if err := repo.FailTwoFactorChallenge(ctx, first); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		failures, err = repo.UseTwoFactorChallenge(ctx, id, uuid.NewString(), since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if failures != 1 {
+			t.Errorf("expected 1 failure, got %d", failures)
+		}
+
+		failures, err = repo.UseTwoFactorChallenge(ctx, id, uuid.NewString(), time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if failures != 0 {
+			t.Errorf("expected failures before the window to be forgotten, got %d", failures)
+		}
+	})
+
+	t.Run("identities link to a single user", func(t *testing.T) {
+		repo := newRepo(t)
+		identity := &user.ExternalIdentity{Provider: "test", Subject: uuid.NewString(), Email: uniqueEmail()}
+
+		created := &user.User{Email: identity.Email, FirstName: "Ext", LastName: "User"}
+		if err := repo.CreateUserWithIdentity(ctx, created, identity); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		userId, err := repo.TouchIdentity(ctx, identity.Provider, identity.Subject)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if userId != created.ID.String() {
+			t.Errorf("expected user %s, got %s", created.ID, userId)
+		}
+
+		other := createUser(t, repo, uniqueEmail())
+		assertStatus(t, repo.LinkIdentity(ctx, other.ID.String(), identity), http.StatusConflict)
+
+		identities, err := repo.FindIdentitiesByUserId(ctx, created.ID.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(identities) != 1 || identities[0].Subject != identity.Subject {
+			t.Errorf("unexpected identities: %+v", identities)
+		}
+
+		if _, err := repo.SoftDeleteUser(ctx, created.ID.String()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, errOf(repo.TouchIdentity(ctx, identity.Provider, identity.Subject)), http.StatusNotFound)
+	})
+
+	t.Run("sessions can be revoked individually and in bulk", func(t *testing.T) {
+		repo := newRepo(t)
+		id := createUser(t, repo, uniqueEmail()).ID.String()
+		client := user.ClientInfo{UserAgent: "test", IP: "127.0.0.1"}
+		expiresAt := time.Now().Add(time.Hour)
+
+		first := createSession(t, repo, id, client, expiresAt)
+		second := createSession(t, repo, id, client, expiresAt)
+		third := createSession(t, repo, id, client, expiresAt)
+		expired := createSession(t, repo, id, client, time.Now().Add(-time.Minute))
+
+		sessions, err := repo.FindActiveSessionsByUserId(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 3 {
+			t.Fatalf("expected 3 active sessions, got %d", len(sessions))
+		}
+
+		assertStatus(t, errOf(repo.FindActiveSessionLastSeen(ctx, expired, id)), http.StatusUnauthorized)
+		assertStatus(t, errOf(repo.FindActiveSessionLastSeen(ctx, first, uuid.NewString())), http.StatusUnauthorized)
+
+		assertStatus(t, repo.RevokeSession(ctx, uuid.NewString(), first), http.StatusNotFound)
+		if err := repo.RevokeSession(ctx, id, first); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, repo.RevokeSession(ctx, id, first), http.StatusNotFound)
+		assertStatus(t, errOf(repo.FindActiveSessionLastSeen(ctx, first, id)), http.StatusUnauthorized)
+
+		if err := repo.RevokeOtherSessions(ctx, id, second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := repo.FindActiveSessionLastSeen(ctx, second, id); err != nil {
+			t.Errorf("expected the kept session to stay active, got %v", err)
+		}
+		assertStatus(t, errOf(repo.FindActiveSessionLastSeen(ctx, third, id)), http.StatusUnauthorized)
+
+		if _, err := repo.SoftDeleteUser(ctx, id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertStatus(t, errOf(repo.FindActiveSessionLastSeen(ctx, second, id)), http.StatusUnauthorized)
+	})
+
+	t.Run("deleted users can be restored within the window", func(t *testing.T) {
+		repo := newRepo(t)
+		email := uniqueEmail()
+		created := createUser(t, repo, email)
+		id := created.ID.String()
+		identity := &user.ExternalIdentity{Provider: "github", Subject: uuid.NewString(), Email: email}
+
+		if err := repo.LinkIdentity(ctx, id, identity); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, repo.RestoreUser(ctx, id, time.Now().Add(-time.Hour)), http.StatusNotFound)
+
+		if _, err := repo.SoftDeleteUser(ctx, id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		since := time.Now().Add(-time.Hour)
+		assertStatus(t, errOf(repo.FindDeletedUserByEmail(ctx, email, time.Now().Add(time.Second))), http.StatusNotFound)
+		assertStatus(t, errOf(repo.FindDeletedUserByIdentity(ctx, identity.Provider, identity.Subject, time.Now().Add(time.Second))), http.StatusNotFound)
+		assertStatus(t, repo.RestoreUser(ctx, id, time.Now().Add(time.Second)), http.StatusNotFound)
+
+		deleted, err := repo.FindDeletedUserByEmail(ctx, strings.ToUpper(email), since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if deleted.ID != created.ID || deleted.PasswordHash != "hash" {
+			t.Errorf("unexpected deleted user: %+v", deleted)
+		}
+
+		deletedId, err := repo.FindDeletedUserByIdentity(ctx, identity.Provider, identity.Subject, since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if deletedId != id {
+			t.Errorf("expected user %s, got %s", id, deletedId)
+		}
+
+		if err := repo.RestoreUser(ctx, id, since); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := repo.FindUserById(ctx, id); err != nil {
+			t.Errorf("expected the restored user to be found, got %v", err)
+		}
+		assertStatus(t, errOf(repo.FindDeletedUserByEmail(ctx, email, since)), http.StatusNotFound)
+
+		if _, err := repo.SoftDeleteUser(ctx, id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		createUser(t, repo, email)
+		assertStatus(t, repo.RestoreUser(ctx, id, since), http.StatusConflict)
+	})
+
+	t.Run("purge removes users deleted before the cutoff", func(t *testing.T) {
+		repo := newRepo(t)
+		deleted := createUser(t, repo, uniqueEmail())
+		kept := createUser(t, repo, uniqueEmail())
+
+		if _, err := repo.SoftDeleteUser(ctx, deleted.ID.String()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repo.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := repo.PurgeDeletedUsers(ctx, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := repo.FindUserById(ctx, kept.ID.String()); err != nil {
+			t.Errorf("expected active user to survive the purge, got %v", err)
+		}
+	})
+}
+
+func createUser(t *testing.T, repo user.Repository, email string) *user.User {
+	t.Helper()
+
+	if err := repo.CreateUser(context.Background(), &user.User{Email: email, FirstName: "Test", LastName: "User", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created, err := repo.FindUserByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return created
+}
+
+func createSession(t *testing.T, repo user.Repository, userId string, client user.ClientInfo, expiresAt time.Time) string {
+	t.Helper()
+
+	id, err := repo.CreateSession(context.Background(), userId, client, "Test device", expiresAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return id
+}
+
+func uniqueEmail() string {
+	return "user-" + uuid.NewString() + "@example.com"
+}
+
+func errOf[T any](_ T, err error) error {
+	return err
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected an AppError with status %d, got %v", status, err)
+	}
+
+	if appErr.StatusCode != status {
+		t.Errorf("expected status %d, got %d", status, appErr.StatusCode)
+	}
+}
@@ -1,9 +1,43 @@
 package user
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type GetUserDetailResponse struct {
 	ID        uuid.UUID `json:"id"`
 	FirstName string    `json:"firstName"`
 	LastName  string    `json:"lastName"`
 }
+
+// LoginResult is the data of every login response. It carries the session
+// token, or only the CSRF token in cookie mode, or the challenge token when
+// a second factor is still required.
+type LoginResult struct {
+	Token             string `json:"token,omitempty"`
+	CSRFToken         string `json:"csrfToken,omitempty"`
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type ProfileResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Email       string     `json:"email"`
+	FirstName   string     `json:"firstName"`
+	LastName    string     `json:"lastName"`
+	TOTPEnabled bool       `json:"totpEnabled"`
+	HasPassword bool       `json:"hasPassword"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,154 @@
+package user
+
+import (
+	"context"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *UserRepository) CreateSession(ctx context.Context, userId string, client ClientInfo, device string, expiresAt time.Time) (string, error) {
+	createQuery := `insert into user_sessions (
+		user_id,
+		user_agent,
+		device,
+		ip,
+		expires_at
+	) values ($1, $2, $3, $4, $5) returning id`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	var sessionId uuid.UUID
+	err := r.Db.QueryRow(ctx, createQuery, userId, client.UserAgent, device, client.IP, expiresAt).Scan(&sessionId)
+	if err != nil {
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return sessionId.String(), nil
+}
+
+func (r *UserRepository) FindActiveSessionsByUserId(ctx context.Context, userId string) ([]Session, error) {
+	fetchQuery := `
+		select id, user_id, user_agent, device, ip, created_at, last_seen_at, expires_at
+		from user_sessions
+		where user_id = $1 and revoked_at is null and expires_at > now()
+		order by last_seen_at desc
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.Db.Query(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+
+	for rows.Next() {
+		var session Session
+
+		err := rows.Scan(
+			&session.Id,
+			&session.UserId,
+			&session.UserAgent,
+			&session.Device,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// FindActiveSessionLastSeen returns when an unrevoked, unexpired session was
+// last used, or ErrUnauthorized if there is no such session.
+func (r *UserRepository) FindActiveSessionLastSeen(ctx context.Context, sessionId, userId string) (time.Time, error) {
+	fetchQuery := `
+		select last_seen_at from user_sessions
+		where id = $1 and user_id = $2 and revoked_at is null and expires_at > now()
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	var lastSeen time.Time
+	err := r.Db.QueryRow(ctx, fetchQuery, sessionId, userId).Scan(&lastSeen)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, appError.ErrUnauthorized
+		}
+
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	return lastSeen, nil
+}
+
+func (r *UserRepository) TouchSession(ctx context.Context, sessionId string) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.Db.Exec(ctx, `update user_sessions set last_seen_at = now() where id = $1`, sessionId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) RevokeSession(ctx context.Context, userId, sessionId string) error {
+	updateQuery := `
+		update user_sessions
+		set revoked_at = now()
+		where id = $1 and user_id = $2 and revoked_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, updateQuery, sessionId, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.NewNotFoundErr("Session not found").WithCode(appError.CodeSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere except keepSessionId,
+// which may be empty to revoke every session.
+func (r *UserRepository) RevokeOtherSessions(ctx context.Context, userId, keepSessionId string) error {
+	updateQuery := `
+		update user_sessions
+		set revoked_at = now()
+		where user_id = $1 and revoked_at is null and ($2 = '' or id::text <> $2)
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.Db.Exec(ctx, updateQuery, userId, keepSessionId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
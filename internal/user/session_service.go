@@ -0,0 +1,102 @@
+package user
+
+import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	sessionTouchInterval = time.Minute
+	maxUserAgentLength   = 512
+)
+
+// ValidateSession implements auth.SessionValidator. last_seen_at is only
+// written once a minute so that authenticated reads don't all become writes.
+func (u *UserService) ValidateSession(ctx context.Context, sessionId, userId string) error {
+	lastSeen, err := u.Repo.FindActiveSessionLastSeen(ctx, sessionId, userId)
+	if err != nil {
+		return err
+	}
+
+	if time.Since(lastSeen) > sessionTouchInterval {
+		if err := u.Repo.TouchSession(ctx, sessionId); err != nil {
+			slog.WarnContext(ctx, "failed to record session activity", "sessionId", sessionId, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (u *UserService) GetSessions(ctx context.Context, userId, currentSessionId string) ([]Session, error) {
+	sessions, err := u.Repo.FindActiveSessionsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id.String() == currentSessionId
+	}
+
+	return sessions, nil
+}
+
+func (u *UserService) RevokeSession(ctx context.Context, userId, sessionId string) error {
+	return u.Repo.RevokeSession(ctx, userId, sessionId)
+}
+
+// startSession records the login and issues a token bound to it, so that
+// revoking the session also invalidates the token.
+func (u *UserService) startSession(ctx context.Context, user *User, client ClientInfo) (string, error) {
+	client.UserAgent = truncate(client.UserAgent, maxUserAgentLength)
+
+	sessionId, err := u.Repo.CreateSession(ctx, user.ID.String(), client, describeDevice(client.UserAgent), time.Now().Add(auth.TokenTTL))
+	if err != nil {
+		return "", err
+	}
+
+	return auth.GenerateToken(user.ID.String(), user.Email, sessionId)
+}
+
+// describeDevice turns a user agent into a short label such as "Firefox on
+// Windows". It only needs to be good enough for a user to recognize their
+// own devices.
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			return browser + " on " + candidate.name
+		}
+	}
+
+	return browser
+}
@@ -0,0 +1,850 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/storage"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteUserRepository is the SQLite Repository, for running the tracker
+// without Postgres.
+type SQLiteUserRepository struct {
+	db       *sql.DB
+	timeouts storage.Timeouts
+}
+
+func NewSQLiteUserRepository(db *sql.DB, timeouts storage.Timeouts) *SQLiteUserRepository {
+	return &SQLiteUserRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+const sqliteEmailIndex = "idx_users_email_lower"
+
+func (r *SQLiteUserRepository) CreateUser(ctx context.Context, user *User) error {
+	createQuery := `insert into users (
+		id,
+		email,
+		first_name,
+		last_name,
+		password_hash
+	) values ($1, $2, $3, $4, $5)`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, createQuery, uuid.NewString(), user.Email, user.FirstName, user.LastName, user.PasswordHash)
+	if err != nil {
+		if storage.IsSQLiteUniqueViolation(err, sqliteEmailIndex) {
+			return appError.ErrDuplicateEmail
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) FindUserByEmail(ctx context.Context, email string) (*User, error) {
+	fetchQuery := `select id, email, first_name, last_name, password_hash, totp_enabled from users
+		where lower(email) = lower($1) and deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	user := new(User)
+	var firstName, lastName sql.NullString
+
+	err := r.db.QueryRowContext(ctx, fetchQuery, email).Scan(
+		&user.ID,
+		&user.Email,
+		&firstName,
+		&lastName,
+		&user.PasswordHash,
+		&user.TOTPEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appError.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	user.FirstName, user.LastName = firstName.String, lastName.String
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) FindUserById(ctx context.Context, id string) (*User, error) {
+	fetchQuery := `select
+		id,
+		email,
+		first_name,
+		last_name,
+		password_hash,
+		totp_secret,
+		totp_enabled,
+		totp_last_used_step,
+		created_at,
+		updated_at
+		from users where id = $1 and deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	user := new(User)
+	var firstName, lastName sql.NullString
+
+	err := r.db.QueryRowContext(ctx, fetchQuery, id).Scan(
+		&user.ID,
+		&user.Email,
+		&firstName,
+		&lastName,
+		&user.PasswordHash,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
+		&user.TOTPLastStep,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appError.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	user.FirstName, user.LastName = firstName.String, lastName.String
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) UpdateProfile(ctx context.Context, userId string, body *UpdateProfileDto) error {
+	if body.FirstName == nil && body.LastName == nil {
+		return nil
+	}
+
+	query := "update users set updated_at = $1"
+	args := []any{storage.SQLiteTime(time.Now())}
+
+	if body.FirstName != nil {
+		args = append(args, *body.FirstName)
+		query += fmt.Sprintf(" , first_name = $%d", len(args))
+	}
+
+	if body.LastName != nil {
+		args = append(args, *body.LastName)
+		query += fmt.Sprintf(" , last_name = $%d", len(args))
+	}
+
+	query += fmt.Sprintf(" where id = $%d and deleted_at is null", len(args)+1)
+	args = append(args, userId)
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(ctx, appError.ErrNotFound, query, args...)
+}
+
+func (r *SQLiteUserRepository) UpdatePasswordHash(ctx context.Context, userId, passwordHash string) error {
+	updateQuery := `update users set password_hash = $1 where id = $2 and deleted_at is null`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(ctx, appError.ErrNotFound, updateQuery, passwordHash, userId)
+}
+
+func (r *SQLiteUserRepository) CreateEmailChangeRequest(ctx context.Context, userId, newEmail, tokenHash string, expiresAt time.Time) error {
+	createQuery := `insert into email_change_requests (
+		id,
+		user_id,
+		new_email,
+		token_hash,
+		expires_at
+	) values ($1, $2, $3, $4, $5)`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, createQuery, uuid.NewString(), userId, newEmail, tokenHash, storage.SQLiteTime(expiresAt)); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange applies a pending email change and discards the user's
+// other pending requests. The unique email index still guards against the
+// address having been taken since the request was made.
+func (r *SQLiteUserRepository) ConfirmEmailChange(ctx context.Context, tokenHash string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		now := storage.SQLiteTime(time.Now())
+
+		confirmQuery := `
+			update email_change_requests
+			set confirmed_at = $1
+			where token_hash = $2 and confirmed_at is null and expires_at > $1
+			returning user_id, new_email
+		`
+
+		var userId uuid.UUID
+		var newEmail string
+
+		err := tx.QueryRowContext(ctx, confirmQuery, now, tokenHash).Scan(&userId, &newEmail)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return appError.NewBadRequestError("Email confirmation link is invalid or has expired").WithCode(appError.CodeEmailConfirmationFailed)
+			}
+
+			return appError.WrapInternalServerError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `update users set email = $1 where id = $2 and deleted_at is null`, newEmail, userId)
+		if err != nil {
+			if storage.IsSQLiteUniqueViolation(err, sqliteEmailIndex) {
+				return appError.ErrDuplicateEmail
+			}
+
+			return appError.WrapInternalServerError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `delete from email_change_requests where user_id = $1 and confirmed_at is null`, userId)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		return nil
+	})
+}
+
+func (r *SQLiteUserRepository) SaveTOTPSecret(ctx context.Context, userId, secret string) error {
+	updateQuery := `
+		update users
+		set totp_secret = $1, totp_last_used_step = null, updated_at = $2
+		where id = $3 and totp_enabled = false and deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(
+		ctx,
+		appError.NewBadRequestError("Two-factor authentication is already enabled").WithCode(appError.CodeTwoFactorEnabled),
+		updateQuery,
+		secret,
+		storage.SQLiteTime(time.Now()),
+		userId,
+	)
+}
+
+// EnableTOTP turns on two-factor authentication and replaces any previous
+// recovery codes in a single transaction.
+func (r *SQLiteUserRepository) EnableTOTP(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		enableQuery := `
+			update users
+			set totp_enabled = true, totp_last_used_step = $1
+			where id = $2 and totp_enabled = false and totp_secret is not null and deleted_at is null
+		`
+
+		result, err := tx.ExecContext(ctx, enableQuery, step, userId)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		if err := affected(result, appError.NewBadRequestError("Two-factor authentication setup has not been started").WithCode(appError.CodeTwoFactorNotStarted)); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `delete from user_recovery_codes where user_id = $1`, userId); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		insertQuery := `insert into user_recovery_codes (id, user_id, code_hash) values ($1, $2, $3)`
+		for _, hash := range recoveryCodeHashes {
+			if _, err := tx.ExecContext(ctx, insertQuery, uuid.NewString(), userId, hash); err != nil {
+				return appError.WrapInternalServerError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ConsumeTOTPStep records step as used. It fails when an equal or later step
+// has already been accepted, which stops replays of the same code.
+func (r *SQLiteUserRepository) ConsumeTOTPStep(ctx context.Context, userId string, step int64) error {
+	updateQuery := `
+		update users
+		set totp_last_used_step = $1
+		where id = $2 and (totp_last_used_step is null or totp_last_used_step < $1)
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(ctx, appError.ErrInvalidTwoFactorCode, updateQuery, step, userId)
+}
+
+func (r *SQLiteUserRepository) ConsumeRecoveryCode(ctx context.Context, userId, codeHash string) error {
+	updateQuery := `
+		update user_recovery_codes
+		set used_at = $1
+		where user_id = $2 and code_hash = $3 and used_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(ctx, appError.ErrInvalidTwoFactorCode, updateQuery, storage.SQLiteTime(time.Now()), userId, codeHash)
+}
+
+func (r *SQLiteUserRepository) DisableTOTP(ctx context.Context, userId string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		disableQuery := `
+			update users
+			set totp_enabled = false, totp_secret = null, totp_last_used_step = null
+			where id = $1 and deleted_at is null
+		`
+
+		if _, err := tx.ExecContext(ctx, disableQuery, userId); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `delete from user_recovery_codes where user_id = $1`, userId); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		return nil
+	})
+}
+
+// ReplaceTOTPSecret swaps the stored secret for newSecret, unless it was
+// changed since oldSecret was read.
+func (r *SQLiteUserRepository) ReplaceTOTPSecret(ctx context.Context, userId, oldSecret, newSecret string) error {
+	updateQuery := `
+		update users
+		set totp_secret = $1
+		where id = $2 and totp_secret = $3
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, updateQuery, newSecret, userId, oldSecret); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorChallenge spends a challenge token, which is only good for one
+// attempt, and returns how many attempts of the user failed since. Older
+// challenges are forgotten on the way.
+func (r *SQLiteUserRepository) UseTwoFactorChallenge(ctx context.Context, userId, challengeId string, since time.Time) (int, error) {
+	var failures int
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `delete from two_factor_challenges where user_id = $1 and used_at < $2`, userId, storage.SQLiteTime(since)); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		insertQuery := `
+			insert into two_factor_challenges (id, user_id, used_at)
+			values ($1, $2, $3)
+			on conflict (id) do nothing
+		`
+
+		result, err := tx.ExecContext(ctx, insertQuery, challengeId, userId, storage.SQLiteTime(time.Now()))
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		if err := affected(result, appError.ErrInvalidChallenge); err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `select count(*) from two_factor_challenges where user_id = $1 and failed`, userId).Scan(&failures)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (r *SQLiteUserRepository) FailTwoFactorChallenge(ctx context.Context, challengeId string) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `update two_factor_challenges set failed = true where id = $1`, challengeId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// TouchIdentity records a login through an already linked identity and
+// returns the id of the user it belongs to.
+func (r *SQLiteUserRepository) TouchIdentity(ctx context.Context, provider, subject string) (string, error) {
+	updateQuery := `
+		update user_identities
+		set last_login_at = $1
+		where provider = $2 and subject = $3
+			and user_id in (select id from users where deleted_at is null)
+		returning user_id
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	var userId uuid.UUID
+	err := r.db.QueryRowContext(ctx, updateQuery, storage.SQLiteTime(time.Now()), provider, subject).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", appError.ErrNotFound
+		}
+
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return userId.String(), nil
+}
+
+func (r *SQLiteUserRepository) LinkIdentity(ctx context.Context, userId string, identity *ExternalIdentity) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return insertSQLiteIdentity(ctx, r.db, userId, identity)
+}
+
+// CreateUserWithIdentity registers a password-less user for a first-time
+// external login. The empty password hash never matches in LoginUser.
+func (r *SQLiteUserRepository) CreateUserWithIdentity(ctx context.Context, user *User, identity *ExternalIdentity) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		createUserQuery := `insert into users (
+			id,
+			email,
+			first_name,
+			last_name,
+			password_hash
+		) values ($1, $2, $3, $4, '')`
+
+		id := uuid.New()
+
+		_, err := tx.ExecContext(ctx, createUserQuery, id, user.Email, user.FirstName, user.LastName)
+		if err != nil {
+			if storage.IsSQLiteUniqueViolation(err, sqliteEmailIndex) {
+				return appError.ErrDuplicateEmail
+			}
+
+			return appError.WrapInternalServerError(err)
+		}
+
+		if err := insertSQLiteIdentity(ctx, tx, id.String(), identity); err != nil {
+			return err
+		}
+
+		user.ID = id
+
+		return nil
+	})
+}
+
+func (r *SQLiteUserRepository) FindIdentitiesByUserId(ctx context.Context, userId string) ([]Identity, error) {
+	fetchQuery := `
+		select provider, subject, email, created_at, last_login_at
+		from user_identities
+		where user_id = $1
+		order by created_at
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, fetchQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []Identity{}
+
+	for rows.Next() {
+		var identity Identity
+
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r *SQLiteUserRepository) CreateSession(ctx context.Context, userId string, client ClientInfo, device string, expiresAt time.Time) (string, error) {
+	createQuery := `insert into user_sessions (
+		id,
+		user_id,
+		user_agent,
+		device,
+		ip,
+		expires_at
+	) values ($1, $2, $3, $4, $5, $6)`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	sessionId := uuid.NewString()
+
+	_, err := r.db.ExecContext(ctx, createQuery, sessionId, userId, client.UserAgent, device, client.IP, storage.SQLiteTime(expiresAt))
+	if err != nil {
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return sessionId, nil
+}
+
+func (r *SQLiteUserRepository) FindActiveSessionsByUserId(ctx context.Context, userId string) ([]Session, error) {
+	fetchQuery := `
+		select id, user_id, user_agent, device, ip, created_at, last_seen_at, expires_at
+		from user_sessions
+		where user_id = $1 and revoked_at is null and expires_at > $2
+		order by last_seen_at desc
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, fetchQuery, userId, storage.SQLiteTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+
+	for rows.Next() {
+		var session Session
+
+		err := rows.Scan(
+			&session.Id,
+			&session.UserId,
+			&session.UserAgent,
+			&session.Device,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// FindActiveSessionLastSeen returns when an unrevoked, unexpired session was
+// last used, or ErrUnauthorized if there is no such session.
+func (r *SQLiteUserRepository) FindActiveSessionLastSeen(ctx context.Context, sessionId, userId string) (time.Time, error) {
+	fetchQuery := `
+		select last_seen_at from user_sessions
+		where id = $1 and user_id = $2 and revoked_at is null and expires_at > $3
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	var lastSeen time.Time
+	err := r.db.QueryRowContext(ctx, fetchQuery, sessionId, userId, storage.SQLiteTime(time.Now())).Scan(&lastSeen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, appError.ErrUnauthorized
+		}
+
+		return time.Time{}, appError.WrapInternalServerError(err)
+	}
+
+	return lastSeen, nil
+}
+
+func (r *SQLiteUserRepository) TouchSession(ctx context.Context, sessionId string) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `update user_sessions set last_seen_at = $1 where id = $2`, storage.SQLiteTime(time.Now()), sessionId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) RevokeSession(ctx context.Context, userId, sessionId string) error {
+	updateQuery := `
+		update user_sessions
+		set revoked_at = $1
+		where id = $2 and user_id = $3 and revoked_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return r.execAffected(ctx, appError.NewNotFoundErr("Session not found").WithCode(appError.CodeSessionNotFound), updateQuery, storage.SQLiteTime(time.Now()), sessionId, userId)
+}
+
+// RevokeOtherSessions signs the user out everywhere except keepSessionId,
+// which may be empty to revoke every session.
+func (r *SQLiteUserRepository) RevokeOtherSessions(ctx context.Context, userId, keepSessionId string) error {
+	updateQuery := `
+		update user_sessions
+		set revoked_at = $1
+		where user_id = $2 and revoked_at is null and id <> $3
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, updateQuery, storage.SQLiteTime(time.Now()), userId, keepSessionId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// SoftDeleteUser marks the account as deleted and revokes every session and
+// personal access token in the same transaction, so nothing issued before
+// the deletion keeps working during the grace period.
+func (r *SQLiteUserRepository) SoftDeleteUser(ctx context.Context, userId string) (time.Time, error) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	nowText := storage.SQLiteTime(now)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `update users set deleted_at = $1 where id = $2 and deleted_at is null`, nowText, userId)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		if err := affected(result, appError.ErrNotFound); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `update user_sessions set revoked_at = $1 where user_id = $2 and revoked_at is null`, nowText, userId)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `update personal_access_tokens set revoked_at = $1 where user_id = $2 and revoked_at is null`, nowText, userId)
+		if err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return now, nil
+}
+
+func (r *SQLiteUserRepository) FindDeletedUserByEmail(ctx context.Context, email string, since time.Time) (*User, error) {
+	fetchQuery := `select id, email, first_name, last_name, password_hash, totp_enabled from users
+		where lower(email) = lower($1) and deleted_at >= $2
+		order by deleted_at desc
+		limit 1
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	user := new(User)
+	var firstName, lastName sql.NullString
+
+	err := r.db.QueryRowContext(ctx, fetchQuery, email, storage.SQLiteTime(since)).Scan(
+		&user.ID,
+		&user.Email,
+		&firstName,
+		&lastName,
+		&user.PasswordHash,
+		&user.TOTPEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appError.ErrNotFound
+		}
+
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	user.FirstName, user.LastName = firstName.String, lastName.String
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) FindDeletedUserByIdentity(ctx context.Context, provider, subject string, since time.Time) (string, error) {
+	fetchQuery := `
+		select i.user_id from user_identities i
+		join users u on u.id = i.user_id
+		where i.provider = $1 and i.subject = $2 and u.deleted_at >= $3
+	`
+
+	ctx, cancel := r.timeouts.ForRead(ctx)
+	defer cancel()
+
+	var userId uuid.UUID
+	err := r.db.QueryRowContext(ctx, fetchQuery, provider, subject, storage.SQLiteTime(since)).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", appError.ErrNotFound
+		}
+
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	return userId.String(), nil
+}
+
+func (r *SQLiteUserRepository) RestoreUser(ctx context.Context, userId string, since time.Time) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `update users set deleted_at = null where id = $1 and deleted_at >= $2`, userId, storage.SQLiteTime(since))
+	if err != nil {
+		if storage.IsSQLiteUniqueViolation(err, sqliteEmailIndex) {
+			return appError.ErrDuplicateEmail
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	return affected(result, appError.ErrNotFound)
+}
+
+// PurgeDeletedUsers hard-deletes accounts soft-deleted before the cutoff.
+// Everything the user owns goes with them through on delete cascade, which
+// OpenSQLite turns on for every connection.
+func (r *SQLiteUserRepository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `delete from users where deleted_at is not null and deleted_at < $1`, storage.SQLiteTime(before))
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return purged, nil
+}
+
+func (r *SQLiteUserRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// execAffected runs an update and returns notFound when it matched nothing.
+func (r *SQLiteUserRepository) execAffected(ctx context.Context, notFound error, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return affected(result, notFound)
+}
+
+func affected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if n == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+type sqliteExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertSQLiteIdentity(ctx context.Context, db sqliteExecer, userId string, identity *ExternalIdentity) error {
+	createQuery := `insert into user_identities (
+		id,
+		user_id,
+		provider,
+		subject,
+		email,
+		last_login_at
+	) values ($1, $2, $3, $4, $5, $6)`
+
+	_, err := db.ExecContext(ctx, createQuery, uuid.NewString(), userId, identity.Provider, identity.Subject, identity.Email, storage.SQLiteTime(time.Now()))
+	if err != nil {
+		if storage.IsSQLiteUniqueViolation(err, "") {
+			return appError.New(
+				err,
+				"Identity is already linked to an account",
+				http.StatusConflict,
+			).WithCode(appError.CodeIdentityLinked)
+		}
+
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package user
+
+import (
+	"context"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"time"
+)
+
+func (r *UserRepository) SaveTOTPSecret(ctx context.Context, userId, secret string) error {
+	updateQuery := `
+		update users
+		set totp_secret = $1, totp_last_used_step = null, updated_at = now()
+		where id = $2 and totp_enabled = false and deleted_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, updateQuery, secret, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.NewBadRequestError("Two-factor authentication is already enabled").WithCode(appError.CodeTwoFactorEnabled)
+	}
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication and replaces any previous
+// recovery codes in a single transaction.
+func (r *UserRepository) EnableTOTP(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	enableQuery := `
+		update users
+		set totp_enabled = true, totp_last_used_step = $1, updated_at = now()
+		where id = $2 and totp_enabled = false and totp_secret is not null and deleted_at is null
+	`
+
+	result, err := tx.Exec(ctx, enableQuery, step, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.NewBadRequestError("Two-factor authentication setup has not been started").WithCode(appError.CodeTwoFactorNotStarted)
+	}
+
+	if _, err := tx.Exec(ctx, `delete from user_recovery_codes where user_id = $1`, userId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	insertQuery := `insert into user_recovery_codes (user_id, code_hash) values ($1, $2)`
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(ctx, insertQuery, userId, hash); err != nil {
+			return appError.WrapInternalServerError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// ConsumeTOTPStep records step as used. It fails when an equal or later step
+// has already been accepted, which stops concurrent replays of the same code.
+func (r *UserRepository) ConsumeTOTPStep(ctx context.Context, userId string, step int64) error {
+	updateQuery := `
+		update users
+		set totp_last_used_step = $1
+		where id = $2 and (totp_last_used_step is null or totp_last_used_step < $1)
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, updateQuery, step, userId)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, userId, codeHash string) error {
+	updateQuery := `
+		update user_recovery_codes
+		set used_at = now()
+		where user_id = $1 and code_hash = $2 and used_at is null
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	result, err := r.Db.Exec(ctx, updateQuery, userId, codeHash)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return appError.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (r *UserRepository) DisableTOTP(ctx context.Context, userId string) error {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	disableQuery := `
+		update users
+		set totp_enabled = false, totp_secret = null, totp_last_used_step = null, updated_at = now()
+		where id = $1 and deleted_at is null
+	`
+
+	if _, err := tx.Exec(ctx, disableQuery, userId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if _, err := tx.Exec(ctx, `delete from user_recovery_codes where user_id = $1`, userId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// ReplaceTOTPSecret swaps the stored secret for newSecret, unless it was
+// changed since oldSecret was read.
+func (r *UserRepository) ReplaceTOTPSecret(ctx context.Context, userId, oldSecret, newSecret string) error {
+	updateQuery := `
+		update users
+		set totp_secret = $1
+		where id = $2 and totp_secret = $3
+	`
+
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.Db.Exec(ctx, updateQuery, newSecret, userId, oldSecret); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorChallenge spends a challenge token, which is only good for one
+// attempt, and returns how many attempts of the user failed since. Older
+// challenges are forgotten on the way.
+func (r *UserRepository) UseTwoFactorChallenge(ctx context.Context, userId, challengeId string, since time.Time) (int, error) {
+	ctx, cancel := r.timeouts.ForTransaction(ctx)
+	defer cancel()
+
+	tx, err := r.Db.Begin(ctx)
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+	defer func() {
+		err = tx.Rollback(ctx)
+		if err != nil {
+			return
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `delete from two_factor_challenges where user_id = $1 and used_at < $2`, userId, since); err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	insertQuery := `
+		insert into two_factor_challenges (id, user_id)
+		values ($1, $2)
+		on conflict (id) do nothing
+	`
+
+	result, err := tx.Exec(ctx, insertQuery, challengeId, userId)
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return 0, appError.ErrInvalidChallenge
+	}
+
+	var failures int
+	err = tx.QueryRow(ctx, `select count(*) from two_factor_challenges where user_id = $1 and failed`, userId).Scan(&failures)
+	if err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, appError.WrapInternalServerError(err)
+	}
+
+	return failures, nil
+}
+
+func (r *UserRepository) FailTwoFactorChallenge(ctx context.Context, challengeId string) error {
+	ctx, cancel := r.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	if _, err := r.Db.Exec(ctx, `update two_factor_challenges set failed = true where id = $1`, challengeId); err != nil {
+		return appError.WrapInternalServerError(err)
+	}
+
+	return nil
+}
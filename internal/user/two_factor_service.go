@@ -0,0 +1,210 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// A user is locked out of two-factor verification after maxTwoFactorFailures
+// failed attempts within twoFactorLockout. The window outlasts challenge
+// tokens, so forgetting older challenges never lets one be spent twice.
+const (
+	maxTwoFactorFailures = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+func (u *UserService) SetupTwoFactor(ctx context.Context, userId string) (*TwoFactorSetupResponse, error) {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, appError.NewBadRequestError("Two-factor authentication is already enabled").WithCode(appError.CodeTwoFactorEnabled)
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := u.TwoFactor.Secrets.Seal(secret)
+	if err != nil {
+		return nil, appError.WrapInternalServerError(err)
+	}
+
+	if err := u.Repo.SaveTOTPSecret(ctx, userId, sealed); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(u.TwoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves
+// their authenticator works. The plain recovery codes are only returned here.
+func (u *UserService) ConfirmTwoFactor(ctx context.Context, userId string, req *ConfirmTwoFactorDto) ([]string, error) {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, appError.NewBadRequestError("Two-factor authentication is already enabled").WithCode(appError.CodeTwoFactorEnabled)
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, appError.NewBadRequestError("Two-factor authentication setup has not been started").WithCode(appError.CodeTwoFactorNotStarted)
+	}
+
+	secret, err := u.totpSecret(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := auth.ValidateTOTP(secret, req.Code, time.Now(), 0)
+	if !ok {
+		return nil, appError.ErrInvalidTwoFactorCode
+	}
+
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	if err := u.Repo.EnableTOTP(ctx, userId, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyTwoFactor exchanges a challenge token and a code for a session.
+// Each challenge token allows a single attempt, and too many failed attempts
+// lock the user out for a while.
+func (u *UserService) VerifyTwoFactor(ctx context.Context, req *VerifyTwoFactorDto, client ClientInfo) (string, error) {
+	claims, err := auth.ParseChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return "", appError.New(err, "Invalid or expired challenge token", http.StatusUnauthorized).WithCode(appError.CodeInvalidChallenge)
+	}
+
+	user, err := u.Repo.FindUserById(ctx, claims.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return "", appError.ErrUnauthorized
+	}
+
+	failures, err := u.Repo.UseTwoFactorChallenge(ctx, user.ID.String(), claims.ID, time.Now().Add(-twoFactorLockout))
+	if err != nil {
+		return "", err
+	}
+
+	if failures >= maxTwoFactorFailures {
+		return "", appError.ErrTwoFactorLocked
+	}
+
+	err = u.checkSecondFactor(ctx, user, req.Code, req.RecoveryCode)
+	if errors.Is(err, appError.ErrInvalidTwoFactorCode) {
+		if err := u.Repo.FailTwoFactorChallenge(ctx, claims.ID); err != nil {
+			return "", err
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return u.startSession(ctx, user, client)
+}
+
+// DisableTwoFactor needs a code or a recovery code, and the password unless
+// the account was created through an identity provider and has none.
+func (u *UserService) DisableTwoFactor(ctx context.Context, userId string, req *DisableTwoFactorDto) error {
+	user, err := u.Repo.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return appError.NewBadRequestError("Two-factor authentication is not enabled").WithCode(appError.CodeTwoFactorNotEnabled)
+	}
+
+	if user.PasswordHash != "" {
+		if err := u.checkPassword(user, req.Password); err != nil {
+			return err
+		}
+	}
+
+	if err := u.checkSecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	return u.Repo.DisableTOTP(ctx, userId)
+}
+
+// checkSecondFactor spends a recovery code when one is given, and checks the
+// TOTP code otherwise.
+func (u *UserService) checkSecondFactor(ctx context.Context, user *User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		return u.Repo.ConsumeRecoveryCode(ctx, user.ID.String(), auth.HashRecoveryCode(recoveryCode))
+	}
+
+	return u.checkTOTP(ctx, user, code)
+}
+
+func (u *UserService) checkTOTP(ctx context.Context, user *User, code string) error {
+	var lastStep int64
+	if user.TOTPLastStep != nil {
+		lastStep = *user.TOTPLastStep
+	}
+
+	secret, err := u.totpSecret(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, time.Now(), lastStep)
+	if !ok {
+		return appError.ErrInvalidTwoFactorCode
+	}
+
+	return u.Repo.ConsumeTOTPStep(ctx, user.ID.String(), step)
+}
+
+// totpSecret opens the user's sealed TOTP secret. A secret stored before an
+// encryption key was configured is sealed on the way, best effort like the
+// rehash of legacy passwords.
+func (u *UserService) totpSecret(ctx context.Context, user *User) (string, error) {
+	secret, legacy, err := u.TwoFactor.Secrets.Open(*user.TOTPSecret)
+	if err != nil {
+		return "", appError.WrapInternalServerError(err)
+	}
+
+	if legacy {
+		sealed, err := u.TwoFactor.Secrets.Seal(secret)
+		if err == nil {
+			err = u.Repo.ReplaceTOTPSecret(ctx, user.ID.String(), *user.TOTPSecret, sealed)
+		}
+
+		if err != nil {
+			slog.WarnContext(ctx, "failed to seal totp secret", "userId", user.ID, "error", err)
+		}
+	}
+
+	return secret, nil
+}
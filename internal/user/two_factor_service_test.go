@@ -0,0 +1,84 @@
+package user_test
+
+import (
+	"context"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// enableTwoFactor turns on TOTP for the user and returns the secret and the
+// recovery codes.
+func enableTwoFactor(t *testing.T, service *user.UserService, userId string) (string, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+	setup, err := service.SetupTwoFactor(ctx, userId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	code, err := auth.GenerateTOTPCode(setup.Secret, auth.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	codes, err := service.ConfirmTwoFactor(ctx, userId, &user.ConfirmTwoFactorDto{Code: code})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return setup.Secret, codes
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("with the password and a code", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		id := registerUser(t, service, uniqueEmail()).ID.String()
+		secret, _ := enableTwoFactor(t, service, id)
+
+		// The step the setup used is spent, the next one is still accepted.
+		code, err := auth.GenerateTOTPCode(secret, auth.TOTPStep(time.Now())+1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, service.DisableTwoFactor(ctx, id, &user.DisableTwoFactorDto{Code: code}), http.StatusBadRequest)
+
+		if err := service.DisableTwoFactor(ctx, id, &user.DisableTwoFactorDto{Password: testPassword, Code: code}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		disabled, err := repo.FindUserById(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if disabled.TOTPEnabled {
+			t.Error("expected two-factor authentication to be disabled")
+		}
+	})
+
+	t.Run("with a recovery code and no password", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		id := oauthUser(t, service).ID.String()
+		_, codes := enableTwoFactor(t, service, id)
+
+		assertStatus(t, service.DisableTwoFactor(ctx, id, &user.DisableTwoFactorDto{RecoveryCode: "not-a-code"}), http.StatusBadRequest)
+
+		if err := service.DisableTwoFactor(ctx, id, &user.DisableTwoFactorDto{RecoveryCode: codes[0]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		disabled, err := repo.FindUserById(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if disabled.TOTPEnabled {
+			t.Error("expected two-factor authentication to be disabled")
+		}
+	})
+}
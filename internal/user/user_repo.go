@@ -2,17 +2,16 @@ package user
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	appError "hafiztri123/hv1-job-tracker/internal/error"
 	"net/http"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func (r *UserRepository) CreateUser(user *User) error {
+func (r *UserRepository) CreateUser(ctx context.Context, user *User) error {
 	createQuery := `insert into users (
 	email, 
 	first_name, 
@@ -20,7 +19,7 @@ func (r *UserRepository) CreateUser(user *User) error {
 	password_hash
 	) values ($1, $2, $3, $4)`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := r.timeouts.ForWrite(ctx)
 	defer cancel()
 
 	_, err := r.Db.Exec(ctx, createQuery, user.Email, user.FirstName, user.LastName, user.PasswordHash)
@@ -51,14 +50,14 @@ func (r *UserRepository) CreateUser(user *User) error {
 	return nil
 }
 
-func (r *UserRepository) FindUserByEmail(email string) (*User, error) {
-	fetchQuery := `select id, email, first_name, last_name, password_hash from users
-		where email = $1 and deleted_at is null
+func (r *UserRepository) FindUserByEmail(ctx context.Context, email string) (*User, error) {
+	fetchQuery := `select id, email, first_name, last_name, password_hash, totp_enabled from users
+		where lower(email) = lower($1) and deleted_at is null
 	`
 
 	user := new(User)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := r.timeouts.ForRead(ctx)
 	defer cancel()
 
 	err := r.Db.QueryRow(ctx, fetchQuery, email).Scan(
@@ -67,10 +66,11 @@ func (r *UserRepository) FindUserByEmail(email string) (*User, error) {
 		&user.FirstName,
 		&user.LastName,
 		&user.PasswordHash,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, appError.ErrNotFound
 		}
 
@@ -82,22 +82,41 @@ func (r *UserRepository) FindUserByEmail(email string) (*User, error) {
 
 }
 
-func (r *UserRepository) FindUserById(id string) (*User, error) {
-	fetchQuery := `select id, first_name, last_name from users where id = $1 and deleted_at is null`
+func (r *UserRepository) FindUserById(ctx context.Context, id string) (*User, error) {
+	fetchQuery := `select 
+		id, 
+		email, 
+		first_name, 
+		last_name, 
+		password_hash, 
+		totp_secret, 
+		totp_enabled, 
+		totp_last_used_step,
+		created_at,
+		updated_at
+		from users where id = $1 and deleted_at is null
+	`
 
 	user := new(User)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := r.timeouts.ForRead(ctx)
 	defer cancel()
 
 	err := r.Db.QueryRow(ctx, fetchQuery, id).Scan(
 		&user.ID,
+		&user.Email,
 		&user.FirstName,
 		&user.LastName,
+		&user.PasswordHash,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
+		&user.TOTPLastStep,
+		&user.CreatedAt,
+		&user.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, appError.ErrNotFound
 		}
 		return nil, err
@@ -1,15 +1,21 @@
 package user
 
 import (
+	"context"
+	"errors"
 	"hafiztri123/hv1-job-tracker/internal/auth"
 	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"log/slog"
 	"net/http"
-
-	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
-func (u *UserService) RegisterUser(req *RegisterUserDto) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 12)
+func (u *UserService) RegisterUser(ctx context.Context, req *RegisterUserDto) error {
+	if errors := u.PasswordPolicy.Check("password", req.Password, req.Email, req.FirstName, req.LastName); errors != nil {
+		return appError.NewValidationError(errors)
+	}
+
+	hashedPassword, err := u.hashPassword(req.Password)
 	if err != nil {
 		return err
 	}
@@ -18,35 +24,147 @@ func (u *UserService) RegisterUser(req *RegisterUserDto) error {
 		Email:        req.Email,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 	}
 
-	if err := u.Repo.CreateUser(user); err != nil {
+	if err := u.Repo.CreateUser(ctx, user); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *UserService) LoginUser(req *LoginUserDto) (string, error) {
-	user, err := u.Repo.FindUserByEmail(req.Email)
+// LoginUser checks the password and returns a session token. Legacy password
+// hashes are upgraded on the way, and an account deleted within the grace
+// period is restored. Users with
+// two-factor authentication get a challenge token instead, which has to be
+// exchanged through VerifyTwoFactor.
+func (u *UserService) LoginUser(ctx context.Context, req *LoginUserDto, client ClientInfo) (*LoginResult, error) {
+	user, err := u.Repo.FindUserByEmail(ctx, req.Email)
+	restore := false
+
+	if errors.Is(err, appError.ErrNotFound) && u.DeletionGracePeriod > 0 {
+		deleted, deletedErr := u.Repo.FindDeletedUserByEmail(ctx, req.Email, u.restorableSince())
+		if deletedErr == nil {
+			user, err, restore = deleted, nil, true
+		} else if !errors.Is(deletedErr, appError.ErrNotFound) {
+			err = deletedErr
+		}
+	}
+
+	// An unknown email fails like a wrong password, in as much time.
+	if errors.Is(err, appError.ErrNotFound) {
+		u.Passwords.VerifyDummy(req.Password)
+		return nil, errInvalidCredentials(err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
 
+	needsRehash, err := u.verifyPassword(user, req.Password)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if restore {
+		if err := u.restoreAccount(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	if needsRehash {
+		u.rehashPassword(ctx, user, req.Password)
+	}
+
+	return u.completeLogin(ctx, user, client)
+}
+
+// completeLogin issues either a session token or, when two-factor
+// authentication is enabled, a challenge token for the second step.
+func (u *UserService) completeLogin(ctx context.Context, user *User, client ClientInfo) (*LoginResult, error) {
+	if user.TOTPEnabled {
+		challenge, err := auth.GenerateChallengeToken(user.ID.String(), user.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
+	token, err := u.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{Token: token}, nil
+}
+
+// restorableSince is the oldest deletion that logging in still undoes.
+func (u *UserService) restorableSince() time.Time {
+	return time.Now().Add(-u.DeletionGracePeriod)
+}
+
+func (u *UserService) restoreAccount(ctx context.Context, userId string) error {
+	if err := u.Repo.RestoreUser(ctx, userId, u.restorableSince()); err != nil {
+		return err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return "", appError.New(
-			err,
-			"Invalid credentials",
-			http.StatusBadRequest,
-		)
+	slog.InfoContext(ctx, "restored deleted account", "userId", userId)
+
+	return nil
+}
+
+func (u *UserService) hashPassword(password string) (string, error) {
+	return u.Passwords.Hash(password)
+}
+
+func (u *UserService) checkPassword(user *User, password string) error {
+	_, err := u.verifyPassword(user, password)
+	return err
+}
+
+// verifyPassword also reports whether the stored hash was made with a legacy
+// algorithm or outdated parameters and should be replaced.
+func (u *UserService) verifyPassword(user *User, password string) (bool, error) {
+	// Accounts created through an identity provider have no hash.
+	if user.PasswordHash == "" {
+		u.Passwords.VerifyDummy(password)
+		return false, errInvalidCredentials(errors.New("account has no password"))
 	}
 
-	token, err := auth.GenerateToken(user.ID.String(), user.Email)
+	needsRehash, err := u.Passwords.Verify(password, user.PasswordHash)
 	if err != nil {
-		return "", err
+		return false, errInvalidCredentials(err)
+	}
+
+	return needsRehash, nil
+}
+
+func errInvalidCredentials(err error) error {
+	return appError.New(
+		err,
+		"Invalid credentials",
+		http.StatusBadRequest,
+	).WithCode(appError.CodeInvalidCredentials)
+}
+
+// rehashPassword is best effort: a failure only means the old hash stays in
+// place until the next login.
+func (u *UserService) rehashPassword(ctx context.Context, user *User, password string) {
+	hashedPassword, err := u.hashPassword(password)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to rehash password", "userId", user.ID, "error", err)
+		return
+	}
+
+	if err := u.Repo.UpdatePasswordHash(ctx, user.ID.String(), hashedPassword); err != nil {
+		slog.WarnContext(ctx, "failed to store rehashed password", "userId", user.ID, "error", err)
+		return
 	}
 
-	return token, err
+	user.PasswordHash = hashedPassword
 }
@@ -0,0 +1,167 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"hafiztri123/hv1-job-tracker/internal/auth"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/mail"
+	"hafiztri123/hv1-job-tracker/internal/password"
+	"hafiztri123/hv1-job-tracker/internal/user"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testPassword = "correct horse battery staple 9"
+
+func newService(t *testing.T, gracePeriod time.Duration) (*user.UserService, *user.MemoryUserRepository) {
+	t.Helper()
+
+	policy, err := password.NewPolicy(password.PolicyConfig{MinLength: 8, MaxLength: 128})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secrets, err := auth.NewSecretCipher(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repo := user.NewMemoryUserRepository()
+	passwords := password.NewArgon2idPasswords(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	return user.NewUserService(repo, mail.LogMailer{}, passwords, policy, user.TwoFactorConfig{Issuer: "Job Tracker", Secrets: secrets}, "", gracePeriod), repo
+}
+
+func registerUser(t *testing.T, service *user.UserService, email string) *user.User {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := service.RegisterUser(ctx, &user.RegisterUserDto{Email: email, FirstName: "Ann", LastName: "Lee", Password: testPassword}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created, err := service.Repo.FindUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return created
+}
+
+func TestLoginFailsAlikeForUnknownEmails(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, time.Hour)
+	registered := registerUser(t, service, uniqueEmail())
+	oauth := oauthUser(t, service)
+
+	logins := map[string]*user.LoginUserDto{
+		"wrong password":  {Email: registered.Email, Password: "wrong password"},
+		"unknown email":   {Email: uniqueEmail(), Password: testPassword},
+		"no password set": {Email: oauth.Email, Password: testPassword},
+	}
+
+	for name, dto := range logins {
+		_, err := service.LoginUser(ctx, dto, user.ClientInfo{})
+
+		var appErr *appError.AppError
+		if !errors.As(err, &appErr) {
+			t.Fatalf("%s: expected an AppError, got %v", name, err)
+		}
+
+		if appErr.StatusCode != http.StatusBadRequest || appErr.Code != appError.CodeInvalidCredentials || appErr.Message != "Invalid credentials" {
+			t.Errorf("%s: expected the invalid credentials error, got %d %s %q", name, appErr.StatusCode, appErr.Code, appErr.Message)
+		}
+	}
+}
+
+func TestLoginRestoresAccountWithinGracePeriod(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("within the grace period", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		email := uniqueEmail()
+		created := registerUser(t, service, email)
+
+		if _, err := service.DeleteAccount(ctx, created.ID.String(), &user.DeleteAccountDto{Password: testPassword}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertStatus(t, errOf(service.LoginUser(ctx, &user.LoginUserDto{Email: email, Password: "wrong password"}, user.ClientInfo{})), http.StatusBadRequest)
+		assertStatus(t, errOf(repo.FindUserById(ctx, created.ID.String())), http.StatusNotFound)
+
+		result, err := service.LoginUser(ctx, &user.LoginUserDto{Email: email, Password: testPassword}, user.ClientInfo{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Token == "" {
+			t.Errorf("expected a session token, got %+v", result)
+		}
+
+		if _, err := repo.FindUserById(ctx, created.ID.String()); err != nil {
+			t.Errorf("expected the account to be restored, got %v", err)
+		}
+
+		purged, err := service.PurgeDeletedAccounts(ctx, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if purged != 0 {
+			t.Errorf("expected the restored account to survive the purge, purged %d", purged)
+		}
+	})
+
+	t.Run("after the grace period", func(t *testing.T) {
+		service, repo := newService(t, 0)
+		email := uniqueEmail()
+		created := registerUser(t, service, email)
+
+		if _, err := service.DeleteAccount(ctx, created.ID.String(), &user.DeleteAccountDto{Password: testPassword}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := service.LoginUser(ctx, &user.LoginUserDto{Email: email, Password: testPassword}, user.ClientInfo{}); err == nil {
+			t.Fatal("expected the login to fail once the grace period is over")
+		}
+		assertStatus(t, errOf(repo.FindUserById(ctx, created.ID.String())), http.StatusNotFound)
+
+		purged, err := service.PurgeDeletedAccounts(ctx, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if purged != 1 {
+			t.Errorf("expected 1 purged account, got %d", purged)
+		}
+	})
+
+	t.Run("through an external identity", func(t *testing.T) {
+		service, repo := newService(t, time.Hour)
+		identity := &user.ExternalIdentity{Provider: "github", Subject: "42", Email: uniqueEmail(), EmailVerified: true}
+
+		if _, err := service.LoginWithIdentity(ctx, identity, user.ClientInfo{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		created, err := repo.FindUserByEmail(ctx, identity.Email)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := service.DeleteAccount(ctx, created.ID.String(), &user.DeleteAccountDto{ConfirmEmail: identity.Email}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := service.LoginWithIdentity(ctx, identity, user.ClientInfo{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		restored, err := repo.FindUserById(ctx, created.ID.String())
+		if err != nil {
+			t.Fatalf("expected the account to be restored, got %v", err)
+		}
+		if restored.Email != identity.Email {
+			t.Errorf("unexpected restored user: %+v", restored)
+		}
+	})
+}
@@ -9,7 +9,7 @@ func GetEnv(key, defaultValue string) string {
 	value, ok := os.LookupEnv(key)
 
 	if !ok {
-		slog.Warn("using default value for env", "key", key)
+		slog.Debug("using default value for env", "key", key)
 		return defaultValue
 	}
 
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"hafiztri123/hv1-job-tracker/internal/i18n"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const ContentTypeProblem = "application/problem+json"
+
+// problemTypeBase prefixes the code to form the RFC 7807 type URI.
+const problemTypeBase = "urn:job-tracker:problem:"
+
+// Problem is the body of every error response, an RFC 7807 problem details
+// object with the stable code, the request id and, for invalid input, the
+// field errors as extension members.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestId string `json:"requestId,omitempty"`
+	Errors    any    `json:"errors,omitempty"`
+	// Debug holds the underlying error in development mode only.
+	Debug string `json:"debug,omitempty"`
+}
+
+// NewProblem describes err in the language the client asked for.
+func NewProblem(c *fiber.Ctx, err *appError.AppError) *Problem {
+	code := appError.CodeOf(err)
+
+	problem := &Problem{
+		Type:     problemTypeBase + code,
+		Title:    http.StatusText(err.StatusCode),
+		Status:   err.StatusCode,
+		Detail:   err.Message,
+		Instance: c.Path(),
+		Code:     code,
+		Errors:   err.Details,
+	}
+
+	problem.RequestId, _ = c.Locals("requestId").(string)
+
+	// Statuses fasthttp has no text for get the message as title.
+	if problem.Title == "" {
+		problem.Title = err.Message
+	}
+
+	trans := Translator(c)
+	problem.Title = i18n.Message(trans, problem.Title)
+	problem.Detail = i18n.Message(trans, problem.Detail)
+
+	// ValidateStruct already translated its messages, this catches the ones
+	// services build, like the password policy.
+	if fieldErrors, ok := err.Details.([]*ErrorResponse); ok {
+		for _, fe := range fieldErrors {
+			fe.Message = fe.translate(trans)
+		}
+	}
+
+	// Generic messages only repeat the title.
+	if problem.Detail == problem.Title {
+		problem.Detail = ""
+	}
+
+	return problem
+}
+
+func SendProblem(c *fiber.Ctx, problem *Problem) error {
+	c.Status(problem.Status)
+	if err := c.JSON(problem); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, ContentTypeProblem)
+	c.Set(fiber.HeaderContentLanguage, Translator(c).Locale())
+	c.Vary(fiber.HeaderAcceptLanguage)
+	return nil
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSendProblem(t *testing.T) {
+	app := fiber.New()
+	app.Get("/problem", func(c *fiber.Ctx) error {
+		c.Locals("requestId", "req-1")
+
+		status := c.QueryInt("status")
+		err := appError.New(errors.New("boom"), "Something failed", status).WithCode(c.Query("code"))
+		return SendProblem(c, NewProblem(c, err))
+	})
+	app.Get("/response", func(c *fiber.Ctx) error {
+		return NewResponse(c, WithStatus(http.StatusNotFound), WithMessage("Application not found"))
+	})
+
+	get := func(path string) (*http.Response, Problem) {
+		t.Helper()
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var problem Problem
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return resp, problem
+	}
+
+	resp, problem := get("/problem?status=409&code=application.version_mismatch")
+	if got := resp.Header.Get(fiber.HeaderContentType); got != ContentTypeProblem {
+		t.Errorf("expected content type %q, got %q", ContentTypeProblem, got)
+	}
+	if resp.StatusCode != http.StatusConflict || problem.Status != http.StatusConflict {
+		t.Errorf("expected status 409, got %d with %d in the body", resp.StatusCode, problem.Status)
+	}
+	if problem.Code != appError.CodeApplicationVersionMismatch || problem.Type != problemTypeBase+problem.Code {
+		t.Errorf("expected the given code, got %q with type %q", problem.Code, problem.Type)
+	}
+	if problem.RequestId != "req-1" {
+		t.Errorf("expected the request id, got %q", problem.RequestId)
+	}
+
+	fallbacks := map[string]string{
+		"/problem?status=400": appError.CodeBadRequest,
+		"/problem?status=404": appError.CodeNotFound,
+		"/problem?status=409": appError.CodeConflict,
+		"/problem?status=418": appError.CodeBadRequest,
+		"/problem?status=503": appError.CodeServiceUnavailable,
+		"/problem?status=500": appError.CodeInternal,
+		"/response":           appError.CodeNotFound,
+	}
+	for path, want := range fallbacks {
+		if _, problem := get(path); problem.Code != want {
+			t.Errorf("%s: expected code %q, got %q", path, want, problem.Code)
+		}
+	}
+
+	resp, problem = get("/response")
+	if got := resp.Header.Get(fiber.HeaderContentType); got != ContentTypeProblem {
+		t.Errorf("expected an error response as %q, got %q", ContentTypeProblem, got)
+	}
+	if problem.RequestId != "" {
+		t.Errorf("expected no request id without the middleware, got %q", problem.RequestId)
+	}
+}
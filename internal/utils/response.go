@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	appError "hafiztri123/hv1-job-tracker/internal/error"
 	"net/http"
 	"reflect"
 
@@ -12,6 +14,9 @@ type Response struct {
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
 	Error   any    `json:"error,omitempty"`
+
+	// Code is only used for errors, see NewResponse.
+	Code string `json:"-"`
 }
 
 type ResponseOption func(*Response)
@@ -56,6 +61,16 @@ func WithError(err any) ResponseOption {
 	}
 }
 
+// WithCode sets the appError code of an error response.
+func WithCode(code string) ResponseOption {
+	return func(r *Response) {
+		r.Code = code
+	}
+}
+
+// NewResponse writes the standard envelope. Error statuses are sent as a
+// Problem instead, with the message as detail and Error as the errors
+// member, so every error looks the same to clients.
 func NewResponse(c *fiber.Ctx, opts ...ResponseOption) error {
 	response := &Response{
 		Status:  http.StatusOK,
@@ -68,5 +83,29 @@ func NewResponse(c *fiber.Ctx, opts ...ResponseOption) error {
 		opt(response)
 	}
 
+	if response.Status >= http.StatusBadRequest {
+		return SendProblem(c, NewProblem(c, responseError(response)))
+	}
+
 	return c.Status(response.Status).JSON(response)
 }
+
+func responseError(r *Response) *appError.AppError {
+	err := appError.New(errors.New(r.Message), r.Message, r.Status).WithCode(r.Code)
+
+	switch details := r.Error.(type) {
+	case nil:
+	case string:
+		// A lone string explains the message, it isn't a list of errors.
+		err.Message = details
+	case []*ErrorResponse:
+		err.Details = details
+		if err.Code == "" {
+			err.Code = appError.CodeValidationFailed
+		}
+	default:
+		err.Details = details
+	}
+
+	return err
+}
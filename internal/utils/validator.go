@@ -1,24 +1,114 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"hafiztri123/hv1-job-tracker/internal/i18n"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
-var validate = validator.New(validator.WithRequiredStructEnabled())
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validate = newValidator()
+
+// validationKeys maps the validator tags in use to their message. Other
+// tags fall back to i18n.ValidationInvalid.
+var validationKeys = map[string]string{
+	"required":         i18n.ValidationRequired,
+	"required_without": i18n.ValidationRequiredWithout,
+	"email":            i18n.ValidationEmail,
+	"url":              i18n.ValidationURL,
+	"numeric":          i18n.ValidationNumeric,
+	"oneof":            i18n.ValidationOneOf,
+	"min":              i18n.ValidationMin,
+	"max":              i18n.ValidationMax,
+	"len":              i18n.ValidationLen,
+}
 
 type ErrorResponse struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+
+	// key and limit keep a LimitError translatable until the client's
+	// language is known.
+	key   string
+	unit  string
+	limit int
+}
+
+// LimitError reports a field over or under a limit with an i18n key like
+// i18n.PasswordMinLength, where {0} is the limit counted in unit. Message is
+// English until NewProblem translates it.
+func LimitError(field, key, unit string, limit int) *ErrorResponse {
+	fe := &ErrorResponse{Field: field, key: key, unit: unit, limit: limit}
+	fe.Message = fe.translate(i18n.Translators()[0])
+
+	return fe
+}
+
+func (fe *ErrorResponse) translate(trans ut.Translator) string {
+	if fe.key == "" {
+		return i18n.Message(trans, fe.Message)
+	}
+
+	param := strconv.Itoa(fe.limit)
+
+	msg, err := trans.T(fe.key, i18n.Count(trans, fe.unit, float64(fe.limit), param))
+	if err != nil {
+		return fe.Message
+	}
+
+	return msg
 }
 
-func ValidateStruct(data any) []*ErrorResponse {
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields the way clients send them.
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return sf.Name
+		}
+
+		return name
+	})
+
+	// The messages live in the i18n catalogs, so registering only hooks the
+	// tags up to translateFieldError.
+	register := func(ut.Translator) error { return nil }
+	for _, trans := range i18n.Translators() {
+		for tag := range validationKeys {
+			if err := v.RegisterTranslation(tag, trans, register, translateFieldError); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	return v
+}
+
+// ValidateStruct validates data and returns the field errors in the
+// language negotiated from the Accept-Language header.
+func ValidateStruct(c *fiber.Ctx, data any) []*ErrorResponse {
 	var errors []*ErrorResponse
 
 	err := validate.Struct(data)
 
 	if err != nil {
+		trans := Translator(c)
+
 		for _, err := range err.(validator.ValidationErrors) {
 			var element ErrorResponse
 			element.Field = err.Field()
-			element.Message = getErrorMsg(err)
+			element.Message = getErrorMsg(trans, err)
 			errors = append(errors, &element)
 		}
 	}
@@ -26,16 +116,87 @@ func ValidateStruct(data any) []*ErrorResponse {
 	return errors
 }
 
-func getErrorMsg(fe validator.FieldError) string {
+// NotNullErrors reports fields that were null but can't be cleared, in the
+// same shape as ValidateStruct.
+func NotNullErrors(c *fiber.Ctx, fields []string) []*ErrorResponse {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	msg, _ := Translator(c).T(i18n.ValidationNotNull)
+
+	errors := make([]*ErrorResponse, len(fields))
+	for i, field := range fields {
+		errors[i] = &ErrorResponse{Field: field, Message: msg}
+	}
+
+	return errors
+}
+
+// Translator is the translator of the language the client asked for.
+func Translator(c *fiber.Ctx) ut.Translator {
+	return i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+}
+
+func getErrorMsg(trans ut.Translator, fe validator.FieldError) string {
+	if _, ok := validationKeys[fe.Tag()]; ok {
+		return fe.Translate(trans)
+	}
+
+	msg, _ := trans.T(i18n.ValidationInvalid)
+	return msg
+}
+
+func translateFieldError(trans ut.Translator, fe validator.FieldError) string {
+	key := validationKeys[fe.Tag()]
+	param := fe.Param()
+
 	switch fe.Tag() {
-	case "required":
-		return "this field is required"
-	case "email":
-		return "invalid email format"
-	case "min":
-		return "value is too short"
-	case "max":
-		return "value is too long"
-	}
-	return "invalid value"
+	case "min", "max", "len":
+		param = countParam(trans, fe)
+	case "oneof":
+		param = strings.ReplaceAll(param, " ", ", ")
+	case "required_without":
+		param = lowerFirst(param)
+	}
+
+	msg, err := trans.T(key, param)
+	if err != nil {
+		msg, _ = trans.T(i18n.ValidationInvalid)
+	}
+
+	return msg
+}
+
+// countParam puts the unit after the limit of strings and lists, so a
+// password gets "8 characters" while a number stays "8".
+func countParam(trans ut.Translator, fe validator.FieldError) string {
+	var unit string
+
+	switch fe.Kind() {
+	case reflect.String:
+		unit = i18n.UnitCharacters
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = i18n.UnitItems
+	default:
+		return fe.Param()
+	}
+
+	n, err := strconv.ParseFloat(fe.Param(), 64)
+	if err != nil {
+		return fe.Param()
+	}
+
+	return i18n.Count(trans, unit, n, fe.Param())
+}
+
+// lowerFirst turns the struct field a tag like required_without refers to
+// into its json name, which in this codebase is the camelCase form.
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+
+	return string(unicode.ToLower(r)) + s[size:]
 }
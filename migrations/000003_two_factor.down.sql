@@ -0,0 +1,7 @@
+drop index if exists idx_user_recovery_codes_user_id;
+drop table if exists user_recovery_codes;
+
+alter table users
+    drop column if exists totp_last_used_step,
+    drop column if exists totp_enabled,
+    drop column if exists totp_secret;
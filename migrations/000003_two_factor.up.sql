@@ -0,0 +1,18 @@
+alter table users
+    add column if not exists totp_secret varchar(64),
+    add column if not exists totp_enabled boolean not null default false,
+    add column if not exists totp_last_used_step bigint;
+
+create table if not exists user_recovery_codes (
+    id uuid primary key default gen_random_uuid(),
+    user_id uuid not null,
+    code_hash varchar(64) not null,
+    used_at timestamptz,
+    created_at timestamptz not null default now(),
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create index if not exists idx_user_recovery_codes_user_id on user_recovery_codes(user_id);
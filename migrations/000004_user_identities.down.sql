@@ -0,0 +1,3 @@
+drop index if exists idx_user_identities_user_id;
+drop index if exists idx_user_identities_provider_subject;
+drop table if exists user_identities;
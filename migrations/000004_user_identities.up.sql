@@ -0,0 +1,17 @@
+create table if not exists user_identities (
+    id uuid primary key default gen_random_uuid(),
+    user_id uuid not null,
+    provider varchar(50) not null,
+    subject varchar(255) not null,
+    email varchar(255),
+    created_at timestamptz not null default now(),
+    last_login_at timestamptz,
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create unique index if not exists idx_user_identities_provider_subject on user_identities(provider, subject);
+
+create index if not exists idx_user_identities_user_id on user_identities(user_id);
@@ -0,0 +1,3 @@
+drop index if exists idx_personal_access_tokens_user_id;
+drop index if exists idx_personal_access_tokens_token_hash;
+drop table if exists personal_access_tokens;
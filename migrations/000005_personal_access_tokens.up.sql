@@ -0,0 +1,19 @@
+create table if not exists personal_access_tokens (
+    id uuid primary key default gen_random_uuid(),
+    user_id uuid not null,
+    name varchar(100) not null,
+    token_hash varchar(64) not null,
+    scopes text[] not null default '{}',
+    expires_at timestamptz not null,
+    last_used_at timestamptz,
+    created_at timestamptz not null default now(),
+    revoked_at timestamptz,
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create unique index if not exists idx_personal_access_tokens_token_hash on personal_access_tokens(token_hash);
+
+create index if not exists idx_personal_access_tokens_user_id on personal_access_tokens(user_id) where revoked_at is null;
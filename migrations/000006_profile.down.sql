@@ -0,0 +1,5 @@
+drop index if exists idx_email_change_requests_token_hash;
+drop table if exists email_change_requests;
+
+drop trigger if exists trg_users_updated_at on users;
+drop function if exists set_updated_at();
@@ -0,0 +1,30 @@
+create or replace function set_updated_at() returns trigger as $$
+begin
+    new.updated_at = now();
+    return new;
+end;
+$$ language plpgsql;
+
+drop trigger if exists trg_users_updated_at on users;
+-- Bookkeeping columns such as totp_last_used_step are left out so that
+-- logging in doesn't count as a profile change.
+create trigger trg_users_updated_at
+    before update of email, first_name, last_name, password_hash, totp_enabled, deleted_at on users
+    for each row
+    execute function set_updated_at();
+
+create table if not exists email_change_requests (
+    id uuid primary key default gen_random_uuid(),
+    user_id uuid not null,
+    new_email varchar(255) not null,
+    token_hash varchar(64) not null,
+    expires_at timestamptz not null,
+    confirmed_at timestamptz,
+    created_at timestamptz not null default now(),
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create unique index if not exists idx_email_change_requests_token_hash on email_change_requests(token_hash);
@@ -0,0 +1,2 @@
+drop index if exists idx_user_sessions_user_id;
+drop table if exists user_sessions;
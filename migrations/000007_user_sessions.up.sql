@@ -0,0 +1,17 @@
+create table if not exists user_sessions (
+    id uuid primary key default gen_random_uuid(),
+    user_id uuid not null,
+    user_agent text,
+    device varchar(100),
+    ip varchar(64),
+    created_at timestamptz not null default now(),
+    last_seen_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    revoked_at timestamptz,
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create index if not exists idx_user_sessions_user_id on user_sessions(user_id) where revoked_at is null;
@@ -0,0 +1,2 @@
+drop index if exists idx_idempotency_keys_expires_at;
+drop table if exists idempotency_keys;
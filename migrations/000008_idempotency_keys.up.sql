@@ -0,0 +1,17 @@
+create table if not exists idempotency_keys (
+    user_id uuid not null,
+    key_hash text not null,
+    fingerprint text not null,
+    status_code int,
+    content_type text,
+    response_body bytea,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    primary key (user_id, key_hash),
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create index if not exists idx_idempotency_keys_expires_at on idempotency_keys(expires_at);
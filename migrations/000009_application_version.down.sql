@@ -0,0 +1 @@
+alter table applications drop column if exists version;
@@ -0,0 +1,3 @@
+-- version counts the changes to an application, for optimistic concurrency
+-- through ETag and If-Match.
+alter table applications add column if not exists version bigint not null default 1;
@@ -0,0 +1,4 @@
+drop table if exists two_factor_challenges;
+
+-- Fails while sealed secrets are stored rather than truncating them.
+alter table users alter column totp_secret type varchar(64);
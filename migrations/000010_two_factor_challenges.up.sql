@@ -0,0 +1,17 @@
+-- Sealed TOTP secrets are longer than the base32 seed.
+alter table users alter column totp_secret type text;
+
+-- two_factor_challenges remembers the challenge tokens that were spent, so
+-- each is good for one attempt, and which ones failed, for the lockout.
+create table if not exists two_factor_challenges (
+    id uuid primary key,
+    user_id uuid not null,
+    failed boolean not null default false,
+    used_at timestamptz not null default now(),
+    constraint fk_user
+        foreign key (user_id)
+        references users(id)
+        on delete cascade
+);
+
+create index if not exists idx_two_factor_challenges_user_id on two_factor_challenges(user_id, used_at);
@@ -0,0 +1,3 @@
+-- lease_id is the reservation that owns an in-progress key, so a request
+-- whose lease was taken over can't store its response over the new one.
+alter table idempotency_keys add column if not exists lease_id uuid;
@@ -0,0 +1,25 @@
+// Package migrations embeds the SQL migrations so the server binary can apply
+// them without the migrate CLI.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS is the migration set of the SQLite backend. It starts from the
+// current schema instead of replaying the Postgres history.
+var SQLiteFS fs.FS
+
+func init() {
+	var err error
+	if SQLiteFS, err = fs.Sub(sqliteFiles, "sqlite"); err != nil {
+		panic(err)
+	}
+}
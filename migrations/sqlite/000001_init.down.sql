@@ -0,0 +1,8 @@
+drop table if exists user_sessions;
+drop table if exists email_change_requests;
+drop table if exists personal_access_tokens;
+drop table if exists user_identities;
+drop table if exists user_recovery_codes;
+drop table if exists applications;
+drop trigger if exists trg_users_updated_at;
+drop table if exists users;
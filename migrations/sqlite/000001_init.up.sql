@@ -0,0 +1,117 @@
+-- SQLite has no uuid or timestamptz types. Ids are uuid strings generated by
+-- the application, and timestamps are UTC text in the fixed-width format of
+-- strftime('%Y-%m-%dT%H:%M:%fZ'), so that comparing them as text compares
+-- them as times. The columns are declared as timestamp so the driver scans
+-- them into time.Time.
+
+create table if not exists users (
+    id text primary key,
+    email text not null,
+    first_name text,
+    last_name text,
+    password_hash text not null,
+    totp_secret text,
+    totp_enabled boolean not null default false,
+    totp_last_used_step integer,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at timestamp,
+    deleted_at timestamp
+);
+
+create unique index if not exists idx_users_email_lower on users(lower(email)) where deleted_at is null;
+
+create index if not exists idx_users_deleted_at on users(deleted_at) where deleted_at is null;
+
+-- Bookkeeping columns such as totp_last_used_step are left out so that
+-- logging in doesn't count as a profile change.
+create trigger if not exists trg_users_updated_at
+    after update of email, first_name, last_name, password_hash, totp_enabled, deleted_at on users
+    for each row
+begin
+    update users set updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') where id = new.id;
+end;
+
+create table if not exists applications (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    company_name text not null,
+    position_title text not null,
+    job_url text,
+    salary_range text,
+    location text,
+    status text default 'Wishlist',
+    notes text,
+    applied_date timestamp,
+    created_at timestamp default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at timestamp,
+    deleted_at timestamp
+);
+
+create index if not exists idx_applications_user_id on applications(user_id) where deleted_at is null;
+
+create table if not exists user_recovery_codes (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    code_hash text not null,
+    used_at timestamp,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+create index if not exists idx_user_recovery_codes_user_id on user_recovery_codes(user_id);
+
+create table if not exists user_identities (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    provider text not null,
+    subject text not null,
+    email text,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    last_login_at timestamp
+);
+
+create unique index if not exists idx_user_identities_provider_subject on user_identities(provider, subject);
+
+create index if not exists idx_user_identities_user_id on user_identities(user_id);
+
+-- scopes is a JSON array of strings.
+create table if not exists personal_access_tokens (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    name text not null,
+    token_hash text not null,
+    scopes text not null default '[]',
+    expires_at timestamp not null,
+    last_used_at timestamp,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    revoked_at timestamp
+);
+
+create unique index if not exists idx_personal_access_tokens_token_hash on personal_access_tokens(token_hash);
+
+create index if not exists idx_personal_access_tokens_user_id on personal_access_tokens(user_id) where revoked_at is null;
+
+create table if not exists email_change_requests (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    new_email text not null,
+    token_hash text not null,
+    expires_at timestamp not null,
+    confirmed_at timestamp,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+create unique index if not exists idx_email_change_requests_token_hash on email_change_requests(token_hash);
+
+create table if not exists user_sessions (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    user_agent text,
+    device text,
+    ip text,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    last_seen_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    expires_at timestamp not null,
+    revoked_at timestamp
+);
+
+create index if not exists idx_user_sessions_user_id on user_sessions(user_id) where revoked_at is null;
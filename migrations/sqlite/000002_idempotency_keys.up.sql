@@ -0,0 +1,13 @@
+create table if not exists idempotency_keys (
+    user_id text not null references users(id) on delete cascade,
+    key_hash text not null,
+    fingerprint text not null,
+    status_code integer,
+    content_type text,
+    response_body blob,
+    created_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    expires_at timestamp not null,
+    primary key (user_id, key_hash)
+);
+
+create index if not exists idx_idempotency_keys_expires_at on idempotency_keys(expires_at);
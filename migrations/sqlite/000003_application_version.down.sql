@@ -0,0 +1 @@
+alter table applications drop column version;
@@ -0,0 +1 @@
+alter table applications add column version integer not null default 1;
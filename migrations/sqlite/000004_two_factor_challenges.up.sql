@@ -0,0 +1,10 @@
+-- two_factor_challenges remembers the challenge tokens that were spent, so
+-- each is good for one attempt, and which ones failed, for the lockout.
+create table if not exists two_factor_challenges (
+    id text primary key,
+    user_id text not null references users(id) on delete cascade,
+    failed boolean not null default false,
+    used_at timestamp not null default (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+create index if not exists idx_two_factor_challenges_user_id on two_factor_challenges(user_id, used_at);
@@ -0,0 +1 @@
+alter table idempotency_keys add column lease_id text;
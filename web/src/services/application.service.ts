@@ -1,10 +1,15 @@
 import { createAxiosInstance } from '@/utils/createAxiosInstance'
 import type { AxiosResponse } from 'axios'
 import type { FetchDetailResponse, FetchListResponse } from './type/response.type'
-import type { CreateApplicationDto, UpdateApplicationDto, Application } from './dto/application.dto'
+import type { CreateApplicationDto, UpdateApplicationDto, PatchApplicationDto, Application } from './dto/application.dto'
 
 const API = createAxiosInstance('applications')
 
+// The server refuses the change with 412 when the application has changed
+// since version was read. Without a version the change always applies, which
+// has to be asked for with "*".
+const ifMatch = (version?: number): Record<string, string> => ({ 'If-Match': version === undefined ? '*' : `"${version}"` })
+
 const ApplicationServices = {
   getApplications: (status?: string, limit?: number, offset?: number): Promise<AxiosResponse<FetchListResponse<Application>>> => {
     const params: Record<string, string | number> = {}
@@ -16,20 +21,23 @@ const ApplicationServices = {
   createApplication: (body: CreateApplicationDto): Promise<AxiosResponse<FetchDetailResponse>> => {
     return API.post('/', body)
   },
-  updateApplication: (id: string, body: UpdateApplicationDto): Promise<AxiosResponse<FetchDetailResponse>> => {
-    return API.put(`/${id}`, body)
+  updateApplication: (id: string, body: UpdateApplicationDto, version?: number): Promise<AxiosResponse<FetchDetailResponse>> => {
+    return API.put(`/${id}`, body, { headers: ifMatch(version) })
+  },
+  patchApplication: (id: string, body: PatchApplicationDto, version?: number): Promise<AxiosResponse<FetchDetailResponse>> => {
+    return API.patch(`/${id}`, body, { headers: { 'Content-Type': 'application/merge-patch+json', ...ifMatch(version) } })
   },
-  deleteApplication: (id: string): Promise<AxiosResponse<FetchDetailResponse>> => {
-    return API.delete(`/${id}`)
+  deleteApplication: (id: string, version?: number): Promise<AxiosResponse<FetchDetailResponse>> => {
+    return API.delete(`/${id}`, { headers: ifMatch(version) })
   },
   getApplicationOptions: (): Promise<AxiosResponse<FetchDetailResponse<{ statusOption: string[] }>>> => {
     return API.get('/options', { params: { statusOption: true } })
   },
-  batchDeleteApplications: (applicationIds: string[]): Promise<AxiosResponse<FetchDetailResponse>> => {
-    return API.delete('/batch/delete', { data: { applicationIds } })
+  batchDeleteApplications: (applicationIds: string[], versions?: Record<string, number>): Promise<AxiosResponse<FetchDetailResponse>> => {
+    return API.delete('/batch/delete', { data: { applicationIds, versions } })
   },
-  batchUpdateStatusApplications: (applicationIds: string[], status: string): Promise<AxiosResponse<FetchDetailResponse>> => {
-    return API.put('/batch/status', { applicationIds, status })
+  batchUpdateStatusApplications: (applicationIds: string[], status: string, versions?: Record<string, number>): Promise<AxiosResponse<FetchDetailResponse>> => {
+    return API.put('/batch/status', { applicationIds, status, versions })
   }
 }
 
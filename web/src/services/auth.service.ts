@@ -1,12 +1,12 @@
 import { createAxiosInstance } from '@/utils/createAxiosInstance'
 import type { AxiosResponse } from 'axios'
 import type { FetchDetailResponse } from './type/response.type'
-import type { LoginBody, RegisterBody } from './dto/auth.dto'
+import type { LoginBody, LoginResult, RegisterBody } from './dto/auth.dto'
 
 const API = createAxiosInstance('auth')
 
 const AuthServices = {
-  login: (body: LoginBody): Promise<AxiosResponse<FetchDetailResponse>> => {
+  login: (body: LoginBody): Promise<AxiosResponse<FetchDetailResponse<LoginResult>>> => {
     return API.post('/login', body)
   },
   register: (body: RegisterBody): Promise<AxiosResponse> => {
@@ -20,6 +20,12 @@ export type UpdateApplicationDto = {
   appliedDate?: string
 }
 
+// PatchApplicationDto is a merge patch: null clears a field, while a
+// missing one is left alone.
+export type PatchApplicationDto = {
+  [K in keyof UpdateApplicationDto]?: UpdateApplicationDto[K] | null
+}
+
 export type Application = {
   id: string
   userId: string
@@ -34,4 +40,5 @@ export type Application = {
   createdAt: string
   updatedAt?: string
   deletedAt?: string
+  version: number
 }
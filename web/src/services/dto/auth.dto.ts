@@ -7,3 +7,10 @@ export type RegisterBody = LoginBody & {
   firstName: string
   lastName: string
 }
+
+export type LoginResult = {
+  token?: string
+  csrfToken?: string
+  twoFactorRequired: boolean
+  challengeToken?: string
+}
@@ -0,0 +1,92 @@
+import { AxiosError } from 'axios'
+
+// Mirrors internal/error/codes.go. Codes are stable, so switch on these
+// rather than on status or detail text.
+export const ErrorCode = {
+  Internal: 'internal',
+  ServiceUnavailable: 'service.unavailable',
+  UpstreamFailed: 'upstream.failed',
+
+  BadRequest: 'request.invalid',
+  ValidationFailed: 'request.validation_failed',
+  TooLarge: 'request.too_large',
+  Timeout: 'request.timeout',
+  RouteNotFound: 'route.not_found',
+  MethodNotAllowed: 'route.method_not_allowed',
+
+  NotFound: 'resource.not_found',
+  Conflict: 'resource.conflict',
+  PreconditionFailed: 'resource.precondition_failed',
+  PreconditionRequired: 'resource.precondition_required',
+
+  IdempotencyKeyInvalid: 'idempotency.key_invalid',
+  IdempotencyKeyReused: 'idempotency.key_reused',
+  IdempotencyInProgress: 'idempotency.in_progress',
+
+  Unauthorized: 'auth.unauthorized',
+  Forbidden: 'auth.forbidden',
+  InvalidCredentials: 'auth.invalid_credentials',
+  InvalidToken: 'auth.invalid_token',
+  InvalidCSRF: 'auth.invalid_csrf',
+  MissingScope: 'auth.missing_scope',
+  InvalidChallenge: 'auth.invalid_challenge',
+  CookieModeDisabled: 'auth.cookie_mode_disabled',
+  InvalidTwoFactorCode: 'two_factor.invalid_code',
+  TwoFactorEnabled: 'two_factor.already_enabled',
+  TwoFactorNotEnabled: 'two_factor.not_enabled',
+  TwoFactorNotStarted: 'two_factor.setup_not_started',
+  TwoFactorLocked: 'two_factor.too_many_attempts',
+
+  EmailTaken: 'user.email_taken',
+  EmailConfirmationFailed: 'user.email_confirmation_invalid',
+  EmailChangeNotConfirmed: 'user.email_change_not_confirmed',
+  DeletionNotConfirmed: 'account.deletion_not_confirmed',
+
+  IdentityLinked: 'identity.already_linked',
+  IdentityEmailNotVerified: 'identity.email_not_verified',
+  ProviderNotFound: 'identity_provider.not_found',
+  ProviderUnavailable: 'identity_provider.unavailable',
+
+  ApplicationNotFound: 'application.not_found',
+  ApplicationVersionMismatch: 'application.version_mismatch',
+  TokenNotFound: 'token.not_found',
+  SessionNotFound: 'session.not_found',
+} as const
+
+export type ErrorCode = (typeof ErrorCode)[keyof typeof ErrorCode]
+
+export type FieldError = {
+  field: string
+  message: string
+}
+
+// RFC 7807 problem details, as returned for every error response.
+export type ProblemDetails = {
+  type: string
+  title: string
+  status: number
+  detail?: string
+  instance?: string
+  code: ErrorCode
+  requestId?: string
+  errors?: FieldError[]
+}
+
+const isProblem = (data: unknown): data is ProblemDetails =>
+  typeof data === 'object' && data !== null && 'code' in data && 'status' in data
+
+// getProblem returns the problem details of a failed request, or undefined
+// for network errors and anything that isn't a request error.
+export const getProblem = (error: unknown): ProblemDetails | undefined => {
+  if (error instanceof AxiosError && isProblem(error.response?.data)) {
+    return error.response.data
+  }
+
+  return undefined
+}
+
+export const problemMessage = (error: unknown, fallback: string): string => {
+  const problem = getProblem(error)
+
+  return problem?.detail || problem?.title || fallback
+}
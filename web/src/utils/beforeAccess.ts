@@ -1,4 +1,5 @@
 import { AuthService } from '@/services'
+import { isCookieAuth } from '@/utils/createAxiosInstance'
 import { Router } from 'vue-router'
 
 export const beforeAccess = (router: Router): void => {
@@ -10,7 +11,7 @@ export const beforeAccess = (router: Router): void => {
     }
 
     const user = JSON.parse(localStorage.getItem('user') ?? '{}')
-    if (!user.token) {
+    if (!user.token && !(isCookieAuth && user.authenticated)) {
       return next({ name: 'auth' })
     }
 
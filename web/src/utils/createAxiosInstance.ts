@@ -2,13 +2,35 @@ import router from '@/router'
 import type { AxiosError, AxiosInstance, InternalAxiosRequestConfig } from 'axios'
 import axios from 'axios'
 
+const apiURL = import.meta.env.VITE_API_URL || 'http://localhost:3000/api/v1'
+
+// In cookie mode the session lives in an HttpOnly cookie the API sets, so the
+// only thing the SPA keeps is the CSRF token, and only in memory.
+export const isCookieAuth = import.meta.env.VITE_AUTH_COOKIE_MODE === 'true'
+
+const safeMethods = ['get', 'head', 'options']
+let csrfToken: string | null = null
+
+export const setCsrfToken = (token: string | null): void => {
+  csrfToken = token
+}
+
+const getCsrfToken = async (): Promise<string | null> => {
+  if (!csrfToken) {
+    const { data } = await axios.get(`${apiURL}/auth/csrf`, { withCredentials: true })
+    csrfToken = data.data.csrfToken
+  }
+
+  return csrfToken
+}
+
 export const createAxiosInstance = (path: string): AxiosInstance => {
-  const url = import.meta.env.VITE_API_URL || 'http://localhost:3000/api/v1'
-  const baseURL = `${url}/${path}`
+  const baseURL = `${apiURL}/${path}`
 
   const instance = axios.create({
     baseURL,
     timeout: 10000,
+    withCredentials: isCookieAuth,
     headers: {
       'Content-Type': 'application/json',
     },
@@ -21,7 +43,21 @@ export const createAxiosInstance = (path: string): AxiosInstance => {
 }
 
 const setAxiosInstanceRequestInterceptor = (instance: AxiosInstance) => {
-  instance.interceptors.request.use((config: InternalAxiosRequestConfig) => {
+  instance.interceptors.request.use(async (config: InternalAxiosRequestConfig) => {
+    if (isCookieAuth) {
+      const method = (config.method || 'get').toLowerCase()
+      const user = JSON.parse(localStorage.getItem('user') || '{}')
+
+      if (user.authenticated && !safeMethods.includes(method) && config.headers) {
+        const token = await getCsrfToken()
+        if (token) {
+          config.headers['X-CSRF-Token'] = token
+        }
+      }
+
+      return config
+    }
+
     const user = JSON.parse(localStorage.getItem('user') || '{}')
 
     if (user.token && config.headers) {
@@ -38,10 +74,10 @@ const setAxiosInstanceResponseInterceptor = (instance: AxiosInstance) => {
   instance.interceptors.response.use((response) => response, (error: AxiosError) => {
     if (error.response?.status === 401) {
       localStorage.removeItem('user')
+      setCsrfToken(null)
       router.push({ name: 'auth' })
     }
 
     return Promise.reject(error)
   })
 }
-